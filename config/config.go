@@ -1,6 +1,14 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
 
 // When defines when a hook should run
 type When string
@@ -13,32 +21,236 @@ const (
 
 // Config represents the unified configuration structure
 type Config struct {
-	ToolsOrder []string        `json:"tools_order,omitempty" yaml:"tools_order,omitempty" toml:"tools_order,omitempty"`
-	Tools      map[string]Tool `json:"tools,omitempty" yaml:"tools,omitempty" toml:"tools,omitempty"`
-	Defaults   *Defaults       `json:"defaults,omitempty" yaml:"defaults,omitempty" toml:"defaults,omitempty"`
-	Settings   *Settings       `json:"settings,omitempty" yaml:"settings,omitempty" toml:"settings,omitempty"`
+	ToolsOrder []string            `json:"tools_order,omitempty" yaml:"tools_order,omitempty" toml:"tools_order,omitempty"`
+	Tools      map[string]Tool     `json:"tools,omitempty" yaml:"tools,omitempty" toml:"tools,omitempty"`
+	Defaults   *Defaults           `json:"defaults,omitempty" yaml:"defaults,omitempty" toml:"defaults,omitempty"`
+	Settings   *Settings           `json:"settings,omitempty" yaml:"settings,omitempty" toml:"settings,omitempty"`
+	Groups     map[string][]string `json:"groups,omitempty" yaml:"groups,omitempty" toml:"groups,omitempty"`
+
+	// numericVersions records versions written as numbers in the source file
+	numericVersions []NumericVersion
 }
 
 // Tool represents a single tool configuration
 type Tool struct {
-	Version     string   `json:"version,omitempty" yaml:"version,omitempty" toml:"version,omitempty"`
-	Exe         string   `json:"exe,omitempty" yaml:"exe,omitempty" toml:"exe,omitempty"`
-	Preinstall  []Hook   `json:"preinstall,omitempty" yaml:"preinstall,omitempty" toml:"preinstall,omitempty"`
-	Postinstall []Hook   `json:"postinstall,omitempty" yaml:"postinstall,omitempty" toml:"postinstall,omitempty"`
-	Depends     []string `json:"depends,omitempty" yaml:"depends,omitempty" toml:"depends,omitempty"`
+	Version     Version                `json:"version,omitempty" yaml:"version,omitempty" toml:"version,omitempty"`
+	Versions    []ToolVersion          `json:"versions,omitempty" yaml:"versions,omitempty" toml:"versions,omitempty"`
+	Exe         string                 `json:"exe,omitempty" yaml:"exe,omitempty" toml:"exe,omitempty"`
+	Options     map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty" toml:"options,omitempty"`
+	Preinstall  []Hook                 `json:"preinstall,omitempty" yaml:"preinstall,omitempty" toml:"preinstall,omitempty"`
+	Postinstall []Hook                 `json:"postinstall,omitempty" yaml:"postinstall,omitempty" toml:"postinstall,omitempty"`
+	Depends     []string               `json:"depends,omitempty" yaml:"depends,omitempty" toml:"depends,omitempty"`
+	Platforms   []string               `json:"platforms,omitempty" yaml:"platforms,omitempty" toml:"platforms,omitempty"`
+	If          *Condition             `json:"if,omitempty" yaml:"if,omitempty" toml:"if,omitempty"`
+	Tags        []string               `json:"tags,omitempty" yaml:"tags,omitempty" toml:"tags,omitempty"`
+	Optional    bool                   `json:"optional,omitempty" yaml:"optional,omitempty" toml:"optional,omitempty"`
+	Enabled     *bool                  `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty"`
+	Env         map[string]string      `json:"env,omitempty" yaml:"env,omitempty" toml:"env,omitempty"` // for the tool's hooks
+}
+
+// ToolVersion is one entry of a tool's versions list.
+// All entries are installed side by side; only the default is activated globally.
+type ToolVersion struct {
+	Version Version `json:"version,omitempty" yaml:"version,omitempty" toml:"version,omitempty"`
+	Default bool    `json:"default,omitempty" yaml:"default,omitempty" toml:"default,omitempty"`
 }
 
 // Hook represents a preinstall or postinstall hook
 type Hook struct {
-	Run         string `json:"run,omitempty" yaml:"run,omitempty" toml:"run,omitempty"`
-	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
-	When        []When `json:"when,omitempty" yaml:"when,omitempty" toml:"when,omitempty"`
+	Run         string            `json:"run,omitempty" yaml:"run,omitempty" toml:"run,omitempty"`
+	File        string            `json:"file,omitempty" yaml:"file,omitempty" toml:"file,omitempty"`    // script file, relative to the config file
+	Shell       Shell             `json:"shell,omitempty" yaml:"shell,omitempty" toml:"shell,omitempty"` // sh, bash, zsh, python, or a command with {0}
+	Cwd         string            `json:"cwd,omitempty" yaml:"cwd,omitempty" toml:"cwd,omitempty"`       // working directory, relative to the config file
+	Env         map[string]string `json:"env,omitempty" yaml:"env,omitempty" toml:"env,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	When        []When            `json:"when,omitempty" yaml:"when,omitempty" toml:"when,omitempty"`
+	Platforms   []string          `json:"platforms,omitempty" yaml:"platforms,omitempty" toml:"platforms,omitempty"`
+	If          *Condition        `json:"if,omitempty" yaml:"if,omitempty" toml:"if,omitempty"`
+
+	// Execution: Timeout and RetryDelay are Go durations (e.g. "30s", "5m")
+	Timeout         string `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+	Retries         int    `json:"retries,omitempty" yaml:"retries,omitempty" toml:"retries,omitempty"`
+	RetryDelay      string `json:"retry_delay,omitempty" yaml:"retry_delay,omitempty" toml:"retry_delay,omitempty"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty" yaml:"continue_on_error,omitempty" toml:"continue_on_error,omitempty"`
+
+	// Guards, checked before the hook runs: skip if the creates path exists,
+	// if the removes path is missing, if unless succeeds or if only_if fails
+	Creates string `json:"creates,omitempty" yaml:"creates,omitempty" toml:"creates,omitempty"`
+	Removes string `json:"removes,omitempty" yaml:"removes,omitempty" toml:"removes,omitempty"`
+	Unless  string `json:"unless,omitempty" yaml:"unless,omitempty" toml:"unless,omitempty"`
+	OnlyIf  string `json:"only_if,omitempty" yaml:"only_if,omitempty" toml:"only_if,omitempty"`
+
+	// dir is the directory of the config file, set by the loader
+	dir string
+}
+
+// FilePath returns the hook's script file, resolved against the directory of
+// the config file it was loaded from; "" for an inline hook
+func (h Hook) FilePath() string {
+	return h.resolve(h.File)
+}
+
+// CwdPath returns the hook's working directory with ~ expanded, resolved
+// against the directory of the config file; "" to inherit mise-seq's
+func (h Hook) CwdPath() string {
+	return h.resolve(h.Cwd)
+}
+
+// CreatesPath returns the hook's creates guard, resolved like CwdPath
+func (h Hook) CreatesPath() string {
+	return h.resolve(h.Creates)
+}
+
+// RemovesPath returns the hook's removes guard, resolved like CwdPath
+func (h Hook) RemovesPath() string {
+	return h.resolve(h.Removes)
+}
+
+// resolve expands ~ in path and resolves it against the config directory
+func (h Hook) resolve(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(h.dir, path)
+}
+
+// MergeEnv merges environment blocks; later blocks override earlier ones.
+// Hooks get the defaults, tool and hook blocks, in that order.
+func MergeEnv(blocks ...map[string]string) map[string]string {
+	var env map[string]string
+	for _, block := range blocks {
+		for name, value := range block {
+			if env == nil {
+				env = make(map[string]string)
+			}
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// envName matches valid environment variable names
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnv checks the variable names of an env block
+func validateEnv(env map[string]string) error {
+	for name := range env {
+		if !envName.MatchString(name) {
+			return fmt.Errorf("invalid env variable name '%s'", name)
+		}
+	}
+	return nil
+}
+
+// Source returns the script the hook runs: run, or the contents of file.
+// Hook state is keyed by it, so editing the file re-triggers the hook.
+func (h Hook) Source() (string, error) {
+	if h.File == "" {
+		return strings.TrimSpace(h.Run), nil
+	}
+	data, err := os.ReadFile(h.FilePath())
+	if err != nil {
+		return "", fmt.Errorf("failed to read hook file: %w", err)
+	}
+	return string(data), nil
+}
+
+// TimeoutDuration returns the hook's timeout; 0 if unset or invalid
+func (h Hook) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(h.Timeout)
+	return d
+}
+
+// RetryDelayDuration returns the wait between attempts; 0 if unset or invalid
+func (h Hook) RetryDelayDuration() time.Duration {
+	d, _ := time.ParseDuration(h.RetryDelay)
+	return d
+}
+
+// validateHook checks the script and execution settings of a hook.
+// A hook without run or file is valid and skipped when it runs.
+func validateHook(h Hook) error {
+	if h.Run != "" && h.File != "" {
+		return fmt.Errorf("'run' and 'file' cannot be used together")
+	}
+	if h.File != "" {
+		if _, err := os.Stat(h.FilePath()); err != nil {
+			return fmt.Errorf("hook file: %w", err)
+		}
+	}
+	if err := ValidateShell(h.Shell); err != nil {
+		return err
+	}
+	if err := validateEnv(h.Env); err != nil {
+		return err
+	}
+	for _, field := range []struct{ name, value string }{{"timeout", h.Timeout}, {"retry_delay", h.RetryDelay}} {
+		if field.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(field.value); err != nil || d < 0 {
+			return fmt.Errorf("invalid %s '%s' (expected a duration like 30s or 5m)", field.name, field.value)
+		}
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got %d", h.Retries)
+	}
+	return nil
+}
+
+// validateHooks checks every hook of a list; what names the list in errors
+func validateHooks(what string, hooks []Hook) error {
+	for n, h := range hooks {
+		if err := validateHook(h); err != nil {
+			return fmt.Errorf("%s hook %d: %w", what, n+1, err)
+		}
+	}
+	return nil
+}
+
+// setDir records the directory of the config file in every hook
+func (c *Config) setDir(dir string) {
+	setHooksDir(c.Defaults.hookLists(), dir)
+	for _, tool := range c.Tools {
+		setHooksDir([][]Hook{tool.Preinstall, tool.Postinstall}, dir)
+	}
+}
+
+// hookLists returns the default hook lists; none for nil
+func (d *Defaults) hookLists() [][]Hook {
+	if d == nil {
+		return nil
+	}
+	return [][]Hook{d.Preinstall, d.Postinstall}
+}
+
+// setHooksDir sets dir in the hooks of each list
+func setHooksDir(lists [][]Hook, dir string) {
+	for _, list := range lists {
+		for n := range list {
+			list[n].dir = dir
+		}
+	}
 }
 
 // Defaults holds default hooks
 type Defaults struct {
-	Preinstall  []Hook `json:"preinstall,omitempty" yaml:"preinstall,omitempty" toml:"preinstall,omitempty"`
-	Postinstall []Hook `json:"postinstall,omitempty" yaml:"postinstall,omitempty"`
+	Preinstall  []Hook            `json:"preinstall,omitempty" yaml:"preinstall,omitempty" toml:"preinstall,omitempty"`
+	Postinstall []Hook            `json:"postinstall,omitempty" yaml:"postinstall,omitempty"`
+	Env         map[string]string `json:"env,omitempty" yaml:"env,omitempty" toml:"env,omitempty"` // for every hook
+}
+
+// DefaultsEnv returns the env block of cfg's defaults; nil if there is none
+func (c *Config) DefaultsEnv() map[string]string {
+	if c == nil || c.Defaults == nil {
+		return nil
+	}
+	return c.Defaults.Env
 }
 
 // Settings holds mise settings
@@ -51,6 +263,77 @@ type NPM struct {
 	PackageManager string `json:"package_manager,omitempty" yaml:"package_manager,omitempty" toml:"package_manager,omitempty"`
 }
 
+// HasMultipleVersions reports whether the tool uses a versions list
+func (t Tool) HasMultipleVersions() bool {
+	return len(t.Versions) > 0
+}
+
+// InstallVersions returns every version to install, with exactly one marked as default.
+// A tool without a versions list yields its single version as the default.
+// If no entry of a versions list is marked default, the first one is.
+func (t Tool) InstallVersions() []ToolVersion {
+	if len(t.Versions) == 0 {
+		return []ToolVersion{{Version: t.Version, Default: true}}
+	}
+
+	versions := make([]ToolVersion, len(t.Versions))
+	copy(versions, t.Versions)
+
+	hasDefault := false
+	for i := range versions {
+		if versions[i].Default && !hasDefault {
+			hasDefault = true
+			continue
+		}
+		versions[i].Default = false
+	}
+	if !hasDefault {
+		versions[0].Default = true
+	}
+	return versions
+}
+
+// DefaultVersion returns the version activated globally for the tool
+func (t Tool) DefaultVersion() Version {
+	for _, v := range t.InstallVersions() {
+		if v.Default {
+			return v.Version
+		}
+	}
+	return t.Version
+}
+
+// IsEnabled reports whether the tool should be installed (enabled defaults to true)
+func (t Tool) IsEnabled() bool {
+	return t.Enabled == nil || *t.Enabled
+}
+
+// MiseOptions returns the mise tool options (exe, matching, bin_path, ...)
+// with the exe field folded in. It returns nil if the tool has no options.
+func (t Tool) MiseOptions() map[string]interface{} {
+	if len(t.Options) == 0 && t.Exe == "" {
+		return nil
+	}
+
+	options := make(map[string]interface{}, len(t.Options)+1)
+	for key, value := range t.Options {
+		options[key] = value
+	}
+	if _, ok := options["exe"]; !ok && t.Exe != "" {
+		options["exe"] = t.Exe
+	}
+	return options
+}
+
+// NumericVersions returns the tool versions that were written as numbers
+// in the source file (e.g. `version: 1.20` instead of `version: "1.20"`)
+func (c *Config) NumericVersions() []NumericVersion {
+	if c == nil {
+		return nil
+	}
+	return c.numericVersions
+}
+
 // HasDefaults checks if config has default hooks
 func HasDefaults(cfg *Config) bool {
 	if cfg == nil || cfg.Defaults == nil {
@@ -114,6 +397,46 @@ func ValidateConfig(cfg *Config) error {
 		}
 	}
 
+	// Validate versions, version constraints and options
+	for name, tool := range cfg.Tools {
+		if err := validateToolVersions(tool); err != nil {
+			return fmt.Errorf("tool '%s': %w", name, err)
+		}
+		if exe, ok := tool.Options["exe"]; ok && tool.Exe != "" && fmt.Sprint(exe) != tool.Exe {
+			return fmt.Errorf("tool '%s': 'exe' (%s) conflicts with options.exe (%v)", name, tool.Exe, exe)
+		}
+		if err := validateToolOptions(tool.MiseOptions()); err != nil {
+			return fmt.Errorf("tool '%s': %w", name, err)
+		}
+		if err := validateEnv(tool.Env); err != nil {
+			return fmt.Errorf("tool '%s': %w", name, err)
+		}
+		if err := validateHooks("preinstall", tool.Preinstall); err != nil {
+			return fmt.Errorf("tool '%s': %w", name, err)
+		}
+		if err := validateHooks("postinstall", tool.Postinstall); err != nil {
+			return fmt.Errorf("tool '%s': %w", name, err)
+		}
+	}
+
+	// Validate default hooks
+	if cfg.Defaults != nil {
+		if err := validateEnv(cfg.Defaults.Env); err != nil {
+			return fmt.Errorf("defaults: %w", err)
+		}
+		if err := validateHooks("defaults preinstall", cfg.Defaults.Preinstall); err != nil {
+			return err
+		}
+		if err := validateHooks("defaults postinstall", cfg.Defaults.Postinstall); err != nil {
+			return err
+		}
+	}
+
+	// Validate groups
+	if err := validateGroups(cfg); err != nil {
+		return err
+	}
+
 	// Validate dependencies
 	if err := ValidateDependencies(cfg); err != nil {
 		return err
@@ -121,3 +444,62 @@ func ValidateConfig(cfg *Config) error {
 
 	return nil
 }
+
+// optionSpecChars cannot appear in tool options: mise reads them inline from
+// "tool[key=value,...]@version" and has no quoting
+const optionSpecChars = ",[]=@"
+
+// validateToolOptions checks that every option key and value can be written
+// into a mise tool spec unchanged
+func validateToolOptions(options map[string]interface{}) error {
+	for key, value := range options {
+		if key == "" || !validOptionText(key) {
+			return fmt.Errorf("invalid option name %q (must not be empty or contain whitespace or any of %s)", key, optionSpecChars)
+		}
+		if text := fmt.Sprint(value); !validOptionText(text) {
+			return fmt.Errorf("option '%s': value %q must not contain whitespace or any of %s", key, text, optionSpecChars)
+		}
+	}
+	return nil
+}
+
+// validOptionText reports whether s has no whitespace and no optionSpecChars
+func validOptionText(s string) bool {
+	return !strings.ContainsAny(s, optionSpecChars) && strings.IndexFunc(s, unicode.IsSpace) < 0
+}
+
+// validateToolVersions checks the version and versions fields of a tool
+func validateToolVersions(tool Tool) error {
+	if tool.Version != "" && len(tool.Versions) > 0 {
+		return fmt.Errorf("'version' and 'versions' cannot be used together")
+	}
+
+	seen := make(map[Version]bool)
+	defaults := 0
+	for _, v := range tool.InstallVersions() {
+		if len(tool.Versions) > 0 && v.Version == "" {
+			return fmt.Errorf("versions entries must set 'version'")
+		}
+		if seen[v.Version] {
+			return fmt.Errorf("version '%s' listed more than once", v.Version)
+		}
+		seen[v.Version] = true
+
+		if IsConstraint(string(v.Version)) {
+			if _, err := ParseConstraint(string(v.Version)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, v := range tool.Versions {
+		if v.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("only one of 'versions' can be marked default")
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfig_MergeDefaults(t *testing.T) {
@@ -208,3 +210,167 @@ func TestValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestTool_InstallVersions(t *testing.T) {
+	tests := []struct {
+		name            string
+		tool            Tool
+		expectedCount   int
+		expectedDefault Version
+	}{
+		{
+			name:            "single version",
+			tool:            Tool{Version: "20"},
+			expectedCount:   1,
+			expectedDefault: "20",
+		},
+		{
+			name: "explicit default",
+			tool: Tool{Versions: []ToolVersion{
+				{Version: "3.11"},
+				{Version: "3.12", Default: true},
+			}},
+			expectedCount:   2,
+			expectedDefault: "3.12",
+		},
+		{
+			name: "first entry is default when unmarked",
+			tool: Tool{Versions: []ToolVersion{
+				{Version: "18"},
+				{Version: "20"},
+			}},
+			expectedCount:   2,
+			expectedDefault: "18",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := tt.tool.InstallVersions()
+			if len(versions) != tt.expectedCount {
+				t.Fatalf("Expected %d versions, got %d", tt.expectedCount, len(versions))
+			}
+
+			defaults := 0
+			for _, v := range versions {
+				if v.Default {
+					defaults++
+				}
+			}
+			if defaults != 1 {
+				t.Errorf("Expected exactly one default, got %d", defaults)
+			}
+
+			if got := tt.tool.DefaultVersion(); got != tt.expectedDefault {
+				t.Errorf("DefaultVersion() = %s, expected %s", got, tt.expectedDefault)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Versions(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      Tool
+		expectErr bool
+	}{
+		{
+			name: "valid versions list",
+			tool: Tool{Versions: []ToolVersion{
+				{Version: "3.11"},
+				{Version: "3.12", Default: true},
+			}},
+		},
+		{
+			name:      "version and versions",
+			tool:      Tool{Version: "3.12", Versions: []ToolVersion{{Version: "3.11"}}},
+			expectErr: true,
+		},
+		{
+			name: "two defaults",
+			tool: Tool{Versions: []ToolVersion{
+				{Version: "3.11", Default: true},
+				{Version: "3.12", Default: true},
+			}},
+			expectErr: true,
+		},
+		{
+			name:      "duplicate version",
+			tool:      Tool{Versions: []ToolVersion{{Version: "20"}, {Version: "20"}}},
+			expectErr: true,
+		},
+		{
+			name:      "empty version entry",
+			tool:      Tool{Versions: []ToolVersion{{Version: ""}}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Tools: map[string]Tool{"python": tt.tool}}
+			err := ValidateConfig(cfg)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ToolOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      Tool
+		expectErr bool
+	}{
+		{"plain values", Tool{Exe: "rg", Options: map[string]interface{}{"matching": "musl", "bin_path": "bin/x86_64", "postinstall_timeout": 30}}, false},
+		{"comma", Tool{Options: map[string]interface{}{"postinstall": "make,install"}}, true},
+		{"closing bracket", Tool{Options: map[string]interface{}{"matching": "a]b"}}, true},
+		{"opening bracket", Tool{Options: map[string]interface{}{"matching": "a[b"}}, true},
+		{"equals", Tool{Options: map[string]interface{}{"postinstall": "PREFIX=/usr make"}}, true},
+		{"space", Tool{Options: map[string]interface{}{"postinstall": "make install"}}, true},
+		{"tab", Tool{Options: map[string]interface{}{"matching": "a\tb"}}, true},
+		{"newline", Tool{Options: map[string]interface{}{"matching": "a\nb"}}, true},
+		{"at sign", Tool{Options: map[string]interface{}{"matching": "a@b"}}, true},
+		{"exe shorthand", Tool{Exe: "my tool"}, true},
+		{"key", Tool{Options: map[string]interface{}{"bin path": "bin"}}, true},
+		{"empty key", Tool{Options: map[string]interface{}{"": "bin"}}, true},
+		{"nested value", Tool{Options: map[string]interface{}{"platforms": map[string]interface{}{"linux": "x"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(&Config{Tools: map[string]Tool{"ubi:foo/bar": tt.tool}})
+			if tt.expectErr && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_HookOptions(t *testing.T) {
+	valid := &Config{Tools: map[string]Tool{
+		"rust": {Postinstall: []Hook{{Run: "cargo install ripgrep", Timeout: "15m", Retries: 2, RetryDelay: "30s"}}},
+	}}
+	if err := ValidateConfig(valid); err != nil {
+		t.Errorf("Expected valid hook options, got %v", err)
+	}
+	if got := valid.Tools["rust"].Postinstall[0].TimeoutDuration(); got != 15*time.Minute {
+		t.Errorf("TimeoutDuration() = %v", got)
+	}
+
+	invalid := &Config{
+		Tools:    map[string]Tool{"jq": {}},
+		Defaults: &Defaults{Preinstall: []Hook{{Run: "true"}, {Run: "make", RetryDelay: "-5s"}}},
+	}
+	err := ValidateConfig(invalid)
+	if err == nil || !strings.Contains(err.Error(), "defaults preinstall hook 2: invalid retry_delay '-5s'") {
+		t.Errorf("Expected an invalid retry_delay error, got %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// constraintOps lists the comparison operators accepted in version constraints.
+// Longer operators come first so that ">=" is not parsed as ">".
+var constraintOps = []string{">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+// constraintVersionRe matches the version part of a constraint term
+var constraintVersionRe = regexp.MustCompile(`^v?\d+(\.\d+){0,2}(-[0-9A-Za-z.-]+)?$`)
+
+// SemVer is a parsed version of the form major[.minor[.patch]][-prerelease].
+// Parts records how many numeric components were written, so that partial
+// versions such as "3.11" can be expanded by the ~ and ^ operators.
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Parts      int
+}
+
+// ParseSemVer parses a version string, accepting an optional "v" prefix and
+// prerelease suffixes written either as "-rc1" or directly as "rc1".
+func ParseSemVer(s string) (SemVer, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return SemVer{}, false
+	}
+
+	// Split numeric core from prerelease suffix
+	end := 0
+	for end < len(s) && (s[end] == '.' || (s[end] >= '0' && s[end] <= '9')) {
+		end++
+	}
+	core := strings.TrimSuffix(s[:end], ".")
+	pre := strings.TrimPrefix(s[end:], "-")
+	if core == "" {
+		return SemVer{}, false
+	}
+
+	fields := strings.Split(core, ".")
+	if len(fields) > 3 {
+		return SemVer{}, false
+	}
+
+	var v SemVer
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return SemVer{}, false
+		}
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+	}
+	v.Parts = len(fields)
+	v.Prerelease = pre
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower, equal or higher than o.
+// A release version is higher than any prerelease of the same core version.
+func (v SemVer) Compare(o SemVer) int {
+	for _, pair := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// comparePrerelease compares two prerelease strings as SemVer 2.0 does:
+// identifier by identifier, numeric identifiers numerically and below
+// alphanumeric ones, and a shorter list below a longer one it prefixes
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// compareIdentifier compares two prerelease identifiers. Identifiers such as
+// "rc10" that mix letters and digits compare their trailing numbers
+// numerically, so that rc10 sorts after rc2 as tools release them.
+func compareIdentifier(a, b string) int {
+	an, aNum := numericIdentifier(a)
+	bn, bNum := numericIdentifier(b)
+	switch {
+	case aNum && bNum:
+		return cmp.Compare(an, bn)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+
+	aText, aDigits := splitTrailingDigits(a)
+	bText, bDigits := splitTrailingDigits(b)
+	if aText == bText && aDigits != "" && bDigits != "" {
+		an, _ := numericIdentifier(aDigits)
+		bn, _ := numericIdentifier(bDigits)
+		return cmp.Compare(an, bn)
+	}
+	return strings.Compare(a, b)
+}
+
+// numericIdentifier parses an identifier made only of digits
+func numericIdentifier(s string) (uint64, bool) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	return n, err == nil
+}
+
+// splitTrailingDigits splits "rc10" into "rc" and "10"
+func splitTrailingDigits(s string) (string, string) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	return s[:i], s[i:]
+}
+
+// String formats the version using the number of parts it was written with
+func (v SemVer) String() string {
+	s := strconv.Itoa(v.Major)
+	if v.Parts >= 2 {
+		s += "." + strconv.Itoa(v.Minor)
+	}
+	if v.Parts >= 3 {
+		s += "." + strconv.Itoa(v.Patch)
+	}
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// constraintTerm is a single comparison such as ">=1.20"
+type constraintTerm struct {
+	op      string
+	version SemVer
+}
+
+// match reports whether v satisfies the term
+func (t constraintTerm) match(v SemVer) bool {
+	c := v.Compare(t.version)
+	switch t.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+// Constraint is a parsed version constraint.
+// Terms within a group are ANDed; groups separated by "||" are ORed.
+type Constraint struct {
+	raw    string
+	groups [][]constraintTerm
+}
+
+// IsConstraint reports whether a version string uses constraint syntax
+// rather than a plain version, prefix or alias understood by mise.
+func IsConstraint(version string) bool {
+	return strings.ContainsAny(strings.TrimSpace(version), "<>=~^!,| ")
+}
+
+// ParseConstraint parses a version constraint such as ">=1.20 <1.23", "~3.11" or "^18 || ^20"
+func ParseConstraint(s string) (*Constraint, error) {
+	raw := strings.TrimSpace(s)
+	if raw == "" {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	c := &Constraint{raw: raw}
+	for _, part := range strings.Split(raw, "||") {
+		group, err := parseConstraintGroup(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint '%s': %w", raw, err)
+		}
+		c.groups = append(c.groups, group)
+	}
+
+	return c, nil
+}
+
+// parseConstraintGroup parses a set of ANDed terms separated by commas or spaces
+func parseConstraintGroup(s string) ([]constraintTerm, error) {
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty constraint group")
+	}
+
+	var terms []constraintTerm
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		op := ""
+		for _, candidate := range constraintOps {
+			if strings.HasPrefix(token, candidate) {
+				op = candidate
+				break
+			}
+		}
+		versionText := strings.TrimPrefix(token, op)
+
+		// Allow whitespace between operator and version (">= 1.20")
+		if versionText == "" && op != "" && i+1 < len(tokens) {
+			i++
+			versionText = tokens[i]
+		}
+
+		if !constraintVersionRe.MatchString(versionText) {
+			return nil, fmt.Errorf("invalid version '%s'", versionText)
+		}
+		version, ok := ParseSemVer(versionText)
+		if !ok {
+			return nil, fmt.Errorf("invalid version '%s'", versionText)
+		}
+
+		terms = append(terms, expandTerm(op, version)...)
+	}
+
+	return terms, nil
+}
+
+// expandTerm converts an operator and a possibly partial version into plain comparisons
+func expandTerm(op string, v SemVer) []constraintTerm {
+	switch op {
+	case "", "=":
+		if v.Parts == 3 {
+			return []constraintTerm{{op: "=", version: v}}
+		}
+		// A partial version matches the whole series: "1.20" = ">=1.20.0 <1.21.0"
+		return []constraintTerm{{op: ">=", version: v}, {op: "<", version: bumpVersion(v, v.Parts-1)}}
+	case "~":
+		// ~1.2.3 and ~1.2 allow patch updates, ~1 allows minor updates
+		idx := 1
+		if v.Parts == 1 {
+			idx = 0
+		}
+		return []constraintTerm{{op: ">=", version: v}, {op: "<", version: bumpVersion(v, idx)}}
+	case "^":
+		// ^ allows changes that do not modify the left-most non-zero component
+		idx := 0
+		if v.Major == 0 && v.Parts > 1 {
+			idx = 1
+			if v.Minor == 0 && v.Parts > 2 {
+				idx = 2
+			}
+		}
+		return []constraintTerm{{op: ">=", version: v}, {op: "<", version: bumpVersion(v, idx)}}
+	case ">", "<=":
+		if v.Parts < 3 {
+			// ">1.20" means above the whole 1.20 series, "<=1.20" includes it
+			upper := bumpVersion(v, v.Parts-1)
+			if op == ">" {
+				return []constraintTerm{{op: ">=", version: upper}}
+			}
+			return []constraintTerm{{op: "<", version: upper}}
+		}
+	}
+	return []constraintTerm{{op: op, version: v}}
+}
+
+// bumpVersion increments the component at idx (0=major, 1=minor, 2=patch)
+// and zeroes everything after it
+func bumpVersion(v SemVer, idx int) SemVer {
+	out := SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Parts: 3}
+	switch idx {
+	case 0:
+		out.Major++
+		out.Minor, out.Patch = 0, 0
+	case 1:
+		out.Minor++
+		out.Patch = 0
+	default:
+		out.Patch++
+	}
+	out.Prerelease = "0"
+	return out
+}
+
+// String returns the constraint as written
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// allowsPrerelease reports whether any term names a prerelease explicitly
+func (c *Constraint) allowsPrerelease() bool {
+	for _, group := range c.groups {
+		for _, term := range group {
+			if term.version.Prerelease != "" && term.version.Prerelease != "0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check reports whether a version string satisfies the constraint.
+// Prereleases only match when the constraint itself mentions one.
+func (c *Constraint) Check(version string) bool {
+	v, ok := ParseSemVer(version)
+	if !ok {
+		return false
+	}
+	if v.Prerelease != "" && !c.allowsPrerelease() {
+		return false
+	}
+
+	for _, group := range c.groups {
+		matched := true
+		for _, term := range group {
+			if !term.match(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Highest returns the highest version from candidates that satisfies the constraint
+func (c *Constraint) Highest(candidates []string) (string, bool) {
+	best := ""
+	var bestVer SemVer
+	for _, candidate := range candidates {
+		if !c.Check(candidate) {
+			continue
+		}
+		v, _ := ParseSemVer(candidate)
+		if best == "" || v.Compare(bestVer) > 0 {
+			best = candidate
+			bestVer = v
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestIsConstraint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"latest", false},
+		{"", false},
+		{"1.22", false},
+		{"20.11.0", false},
+		{"lts", false},
+		{">=1.20 <1.23", true},
+		{"~3.11", true},
+		{"^18", true},
+		{">=1.20,<1.23", true},
+		{"^18 || ^20", true},
+	}
+
+	for _, tt := range tests {
+		if result := IsConstraint(tt.input); result != tt.expected {
+			t.Errorf("IsConstraint(%q) = %v, expected %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		">=",
+		">=latest",
+		"~foo",
+		">=1.2.3.4",
+		"^18 ||",
+	}
+
+	for _, input := range tests {
+		if _, err := ParseConstraint(input); err == nil {
+			t.Errorf("ParseConstraint(%q) expected error", input)
+		}
+	}
+}
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=1.20 <1.23", "1.20.0", true},
+		{">=1.20 <1.23", "1.22.9", true},
+		{">=1.20 <1.23", "1.23.0", false},
+		{">=1.20 <1.23", "1.19.13", false},
+		{">= 1.20, < 1.23", "1.21.5", true},
+		{"~3.11", "3.11.9", true},
+		{"~3.11", "3.12.0", false},
+		{"~3", "3.13.1", true},
+		{"~3", "4.0.0", false},
+		{"^18", "18.20.4", true},
+		{"^18", "19.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^18 || ^20", "20.1.0", true},
+		{"^18 || ^20", "19.9.0", false},
+		{">1.20", "1.20.5", false},
+		{">1.20", "1.21.0", true},
+		{"<=1.20", "1.20.5", true},
+		{"!=1.21.0 >=1.21", "1.21.0", false},
+		{"~3.13", "3.13.0rc1", false},
+		{">=3.13.0-rc1", "3.13.0-rc2", true},
+		{">=1.0", "latest", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) failed: %v", tt.constraint, err)
+		}
+		if result := c.Check(tt.version); result != tt.expected {
+			t.Errorf("%q.Check(%q) = %v, expected %v", tt.constraint, tt.version, result, tt.expected)
+		}
+	}
+}
+
+func TestConstraint_Highest(t *testing.T) {
+	candidates := []string{"1.19.13", "1.20.0", "1.20.14", "1.21.13", "1.22.7", "1.23.0rc1", "1.23.2"}
+
+	c, err := ParseConstraint(">=1.20 <1.23")
+	if err != nil {
+		t.Fatalf("ParseConstraint failed: %v", err)
+	}
+
+	best, ok := c.Highest(candidates)
+	if !ok {
+		t.Fatal("Expected a matching version")
+	}
+	if best != "1.22.7" {
+		t.Errorf("Expected 1.22.7, got %s", best)
+	}
+
+	c, _ = ParseConstraint("^2")
+	if _, ok := c.Highest(candidates); ok {
+		t.Error("Expected no match for ^2")
+	}
+}
+
+func TestValidateConfig_InvalidConstraint(t *testing.T) {
+	cfg := &Config{
+		Tools: map[string]Tool{
+			"go": {Version: ">=1.20 <"},
+		},
+	}
+
+	if err := ValidateConfig(cfg); err == nil {
+		t.Error("Expected error for invalid version constraint")
+	}
+}
+
+func TestSemVer_ComparePrerelease(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0-rc2", "1.0.0-rc10", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-rc1", "1.0.0-rc1", 0},
+	}
+
+	for _, tt := range tests {
+		a, _ := ParseSemVer(tt.a)
+		b, _ := ParseSemVer(tt.b)
+		if result := a.Compare(b); result != tt.expected {
+			t.Errorf("Compare(%q, %q) = %d, expected %d", tt.a, tt.b, result, tt.expected)
+		}
+		if result := b.Compare(a); result != -tt.expected {
+			t.Errorf("Compare(%q, %q) = %d, expected %d", tt.b, tt.a, result, -tt.expected)
+		}
+	}
+
+	c, _ := ParseConstraint(">=1.0.0-rc1")
+	if best, _ := c.Highest([]string{"1.0.0-rc2", "1.0.0-rc10", "1.0.0-rc9"}); best != "1.0.0-rc10" {
+		t.Errorf("Expected 1.0.0-rc10, got %s", best)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"cuelang.org/go/cue"
@@ -35,6 +36,14 @@ func parseCUEFile(filename string) (*Config, error) {
 		return nil, fmt.Errorf("CUE decode error: %v", err)
 	}
 
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if config.numericVersions, err = FindNumericVersions(data, "cue"); err != nil {
+		return nil, err
+	}
+
 	// Merge defaults
 	config.MergeDefaults()
 
@@ -23,6 +23,12 @@ type RuntimeConfig struct {
 	// StateDir - custom state directory (default: $XDG_CACHE_HOME/tools/state/)
 	StateDir string
 
+	// LogDir - directory of per-run step logs (default: $XDG_CACHE_HOME/tools/logs/)
+	LogDir string
+
+	// LogRetention - how many runs (or how old) logs are kept (default: 10)
+	LogRetention string
+
 	// CUEVersion - version of cue to bootstrap
 	CUEVersion string
 
@@ -68,6 +74,16 @@ func LoadRuntimeConfig() *RuntimeConfig {
 		cfg.StateDir = stateDir
 	}
 
+	// Step logs
+	cfg.LogDir = os.Getenv("LOG_DIR")
+	if cfg.LogDir == "" {
+		cfg.LogDir = defaultLogDir()
+	}
+	cfg.LogRetention = os.Getenv("LOG_RETENTION")
+	if cfg.LogRetention == "" {
+		cfg.LogRetention = "10"
+	}
+
 	// Mise paths
 	cfg.MiseShimsDefault = os.Getenv("MISE_SHIMS_DEFAULT")
 	if cfg.MiseShimsDefault == "" {
@@ -91,6 +107,20 @@ func LoadRuntimeConfig() *RuntimeConfig {
 	return cfg
 }
 
+// defaultLogDir returns $XDG_CACHE_HOME/tools/logs, next to the hook state
+func defaultLogDir() string {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		if home := os.Getenv("HOME"); home != "" {
+			cacheDir = filepath.Join(home, ".cache")
+		}
+	}
+	if cacheDir == "" {
+		cacheDir = "/tmp"
+	}
+	return filepath.Join(cacheDir, "tools", "logs")
+}
+
 // SetupEnvironment prepares the environment for mise
 func (c *RuntimeConfig) SetupEnvironment() error {
 	// Build PATH with mise shims
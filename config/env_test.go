@@ -37,6 +37,27 @@ func TestLoadRuntimeConfig_Defaults(t *testing.T) {
 	}
 }
 
+func TestLoadRuntimeConfig_LogDir(t *testing.T) {
+	t.Setenv("LOG_DIR", "")
+	t.Setenv("LOG_RETENTION", "")
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+
+	cfg := LoadRuntimeConfig()
+	if cfg.LogDir != filepath.Join("/cache", "tools", "logs") {
+		t.Errorf("Unexpected default LogDir %s", cfg.LogDir)
+	}
+	if cfg.LogRetention != "10" {
+		t.Errorf("Expected LogRetention=10 by default, got %s", cfg.LogRetention)
+	}
+
+	t.Setenv("LOG_DIR", "/custom/logs")
+	t.Setenv("LOG_RETENTION", "7d")
+	cfg = LoadRuntimeConfig()
+	if cfg.LogDir != "/custom/logs" || cfg.LogRetention != "7d" {
+		t.Errorf("Expected LOG_DIR and LOG_RETENTION to be used, got %s and %s", cfg.LogDir, cfg.LogRetention)
+	}
+}
+
 func TestLoadRuntimeConfig_EnvVars(t *testing.T) {
 	// Set env vars
 	os.Setenv("DRY_RUN", "1")
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -39,18 +41,37 @@ func (l *Loader) Parse(path string) (*Config, error) {
 	}
 
 	// Parse based on format
+	var (
+		cfg *Config
+		err error
+	)
 	switch format {
 	case "json":
-		return ParseJSON(path)
+		cfg, err = ParseJSON(path)
 	case "yaml", "yml":
-		return ParseYAML(path)
+		cfg, err = ParseYAML(path)
 	case "toml":
-		return ParseTOML(path)
+		cfg, err = ParseTOML(path)
 	case "cue":
-		return ParseCUE(path)
+		cfg, err = ParseCUE(path)
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Hook files are relative to the config file
+	if abs, err := filepath.Abs(path); err == nil {
+		cfg.setDir(filepath.Dir(abs))
+	}
+
+	// Numbers are accepted but easy to get wrong (1.20 is not 1.2)
+	for _, nv := range cfg.NumericVersions() {
+		Warn("%s:%d: version of '%s' is written as a number (%s); quote it or run 'mise-seq fmt'", path, nv.Line, nv.Tool, nv.Text)
+	}
+
+	return cfg, nil
 }
 
 // ParseFile is a convenience function to parse a config file
@@ -106,3 +127,14 @@ func NormalizePath(path string) (string, error) {
 	}
 	return absPath, nil
 }
+
+// HashFile returns the SHA256 of a config file's contents, used to tell
+// whether a plan or checkpoint was made from the same config
+func HashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
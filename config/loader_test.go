@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -96,3 +97,160 @@ func TestNormalizePath(t *testing.T) {
 		t.Errorf("Expected absolute path, got %s", path)
 	}
 }
+
+func TestParseToolOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"JSON", "tools.json", `{"tools": {"ubi:BurntSushi/ripgrep": {"exe": "rg", "options": {"matching": "musl", "bin_path": "bin"}}}}`},
+		{"YAML", "tools.yaml", "tools:\n  ubi:BurntSushi/ripgrep:\n    exe: rg\n    options:\n      matching: musl\n      bin_path: bin\n"},
+		{"TOML", "tools.toml", "[tools.\"ubi:BurntSushi/ripgrep\"]\nexe = \"rg\"\n\n[tools.\"ubi:BurntSushi/ripgrep\".options]\nmatching = \"musl\"\nbin_path = \"bin\"\n"},
+		{"CUE", "tools.cue", "tools: \"ubi:BurntSushi/ripgrep\": {\n\texe: \"rg\"\n\toptions: {matching: \"musl\", bin_path: \"bin\"}\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			options := cfg.Tools["ubi:BurntSushi/ripgrep"].MiseOptions()
+			expected := map[string]string{"exe": "rg", "matching": "musl", "bin_path": "bin"}
+			if len(options) != len(expected) {
+				t.Fatalf("Expected %d options, got %v", len(expected), options)
+			}
+			for key, value := range expected {
+				if options[key] != value {
+					t.Errorf("options[%s] = %v, expected %s", key, options[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ExeConflict(t *testing.T) {
+	cfg := &Config{
+		Tools: map[string]Tool{
+			"ripgrep": {Exe: "rg", Options: map[string]interface{}{"exe": "ripgrep"}},
+		},
+	}
+
+	if err := ValidateConfig(cfg); err == nil {
+		t.Error("Expected error for conflicting exe and options.exe")
+	}
+}
+
+func TestLoader_HookFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hooks", "setup.py"), []byte("print('hi')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "tools.yaml")
+	data := `
+tools:
+  python:
+    postinstall:
+      - file: hooks/setup.py
+        shell: python
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	hook := cfg.Tools["python"].Postinstall[0]
+	if got, want := hook.FilePath(), filepath.Join(dir, "hooks", "setup.py"); got != want {
+		t.Errorf("FilePath() = %s, expected %s", got, want)
+	}
+	if source, err := hook.Source(); err != nil || source != "print('hi')\n" {
+		t.Errorf("Source() = %q, %v", source, err)
+	}
+
+	for _, tt := range []struct {
+		hook Hook
+		err  string
+	}{
+		{Hook{Run: "make", File: "hooks/setup.py"}, "cannot be used together"},
+		{Hook{File: "missing.sh"}, "hook file"},
+		{Hook{Run: "make", Shell: Shell{"fish"}}, "unknown shell 'fish'"},
+		{Hook{Run: "make", Shell: ParseShell("perl {0}")}, ""},
+		{Hook{Run: "make", Shell: Shell{"pwsh", "-Command", "& {0}"}}, ""},
+		{Hook{}, ""}, // an empty hook is a no-op
+	} {
+		err := ValidateConfig(&Config{Tools: map[string]Tool{"jq": {Preinstall: []Hook{tt.hook}}}})
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("ValidateConfig(%+v) = %v, expected %q", tt.hook, err, tt.err)
+		}
+	}
+}
+
+func TestLoader_HookEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.yaml")
+	data := `
+defaults:
+  env:
+    LEVEL: defaults
+    REGION: eu
+tools:
+  node:
+    env:
+      LEVEL: tool
+    postinstall:
+      - run: npm ci
+        cwd: web
+        creates: web/node_modules
+        env:
+          LEVEL: hook
+      - run: make
+        cwd: ~/src
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	hooks := cfg.Tools["node"].Postinstall
+	if got, want := hooks[0].CwdPath(), filepath.Join(dir, "web"); got != want {
+		t.Errorf("CwdPath() = %s, expected %s", got, want)
+	}
+	if got, want := hooks[0].CreatesPath(), filepath.Join(dir, "web", "node_modules"); got != want {
+		t.Errorf("CreatesPath() = %s, expected %s", got, want)
+	}
+	home, _ := os.UserHomeDir()
+	if got, want := hooks[1].CwdPath(), filepath.Join(home, "src"); got != want {
+		t.Errorf("CwdPath() = %s, expected %s", got, want)
+	}
+	env := MergeEnv(cfg.DefaultsEnv(), cfg.Tools["node"].Env, hooks[0].Env)
+	if env["LEVEL"] != "hook" || env["REGION"] != "eu" {
+		t.Errorf("MergeEnv() = %v", env)
+	}
+
+	invalid := &Config{Tools: map[string]Tool{"jq": {Env: map[string]string{"BAD-NAME": "1"}}}}
+	if err := ValidateConfig(invalid); err == nil || !strings.Contains(err.Error(), "invalid env variable name 'BAD-NAME'") {
+		t.Errorf("Expected an invalid env name error, got %v", err)
+	}
+}
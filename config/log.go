@@ -1,69 +1,329 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
-// LogLevel represents the logging level
+// LogLevel represents the logging level. Lower levels are more verbose;
+// the values match log/slog.
 type LogLevel int
 
 const (
-	LogLevelInfo LogLevel = iota
-	LogLevelWarn
-	LogLevelError
-	LogLevelDebug
+	LogLevelDebug LogLevel = LogLevel(slog.LevelDebug)
+	LogLevelInfo  LogLevel = LogLevel(slog.LevelInfo)
+	LogLevelWarn  LogLevel = LogLevel(slog.LevelWarn)
+	LogLevelError LogLevel = LogLevel(slog.LevelError)
 )
 
-// Logger provides logging functionality
+// LogFormat is the format of console log output
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text" // "[time] LEVEL: message key=value"
+	LogFormatJSON LogFormat = "json" // one JSON object per line
+)
+
+// ParseLogFormat parses a --log-format value
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch LogFormat(s) {
+	case LogFormatText, LogFormatJSON:
+		return LogFormat(s), nil
+	}
+	return "", fmt.Errorf("unknown log format '%s' (expected text or json)", s)
+}
+
+// LoggerOptions configures a Logger
+type LoggerOptions struct {
+	Level  LogLevel
+	Format LogFormat
+	Output io.Writer // console output; defaults to stderr
+
+	// File, if set, receives every record at debug level as JSON lines,
+	// in addition to the console output. It is appended to.
+	File string
+
+	// Mask lists secret values replaced with Masked in both outputs
+	Mask []string
+}
+
+// Logger provides logging functionality on top of log/slog
 type Logger struct {
-	level LogLevel
+	logger *slog.Logger
+	file   *os.File
 }
 
-// NewLogger creates a new logger
+// NewLogger creates a new logger writing text to stderr
 func NewLogger(debug bool) *Logger {
 	level := LogLevelInfo
 	if debug {
 		level = LogLevelDebug
 	}
-	return &Logger{level: level}
+	logger, _ := NewLoggerWithOptions(LoggerOptions{Level: level})
+	return logger
+}
+
+// NewLoggerWithOptions creates a logger; it fails only if the log file
+// cannot be opened
+func NewLoggerWithOptions(opts LoggerOptions) (*Logger, error) {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	out = NewMaskingWriter(out, opts.Mask)
+	handlerOpts := &slog.HandlerOptions{Level: slog.Level(opts.Level)}
+
+	var handler slog.Handler
+	if opts.Format == LogFormatJSON {
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	} else {
+		handler = newTextHandler(out, handlerOpts.Level)
+	}
+
+	l := &Logger{}
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		l.file = f
+		handler = fanoutHandler{handler, slog.NewJSONHandler(NewMaskingWriter(f, opts.Mask), &slog.HandlerOptions{Level: slog.LevelDebug})}
+	}
+
+	l.logger = slog.New(contextHandler{handler})
+	return l, nil
+}
+
+// Slog returns the underlying slog logger
+func (l *Logger) Slog() *slog.Logger {
+	return l.logger
+}
+
+// With returns a logger adding attributes to every record
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{logger: l.logger.With(args...), file: l.file}
+}
+
+// Close closes the log file, if any
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
 }
 
 // LogInfo logs an info message
 func (l *Logger) LogInfo(format string, args ...interface{}) {
-	if l.level <= LogLevelInfo {
-		l.log("INFO", format, args...)
-	}
+	l.log(context.Background(), LogLevelInfo, format, args...)
 }
 
 // LogWarn logs a warning message
 func (l *Logger) LogWarn(format string, args ...interface{}) {
-	if l.level <= LogLevelWarn {
-		l.log("WARN", format, args...)
-	}
+	l.log(context.Background(), LogLevelWarn, format, args...)
 }
 
 // LogError logs an error message
 func (l *Logger) LogError(format string, args ...interface{}) {
-	l.log("ERROR", format, args...)
+	l.log(context.Background(), LogLevelError, format, args...)
 }
 
 // LogDebug logs a debug message
 func (l *Logger) LogDebug(format string, args ...interface{}) {
-	if l.level <= LogLevelDebug {
-		l.log("DEBUG", format, args...)
-	}
+	l.log(context.Background(), LogLevelDebug, format, args...)
 }
 
-// log outputs a log message with timestamp
-func (l *Logger) log(level, format string, args ...interface{}) {
+// log formats and logs a message, with the attributes of ctx
+func (l *Logger) log(ctx context.Context, level LogLevel, format string, args ...interface{}) {
+	if !l.logger.Enabled(ctx, slog.Level(level)) {
+		return
+	}
 	msg := format
 	if len(args) > 0 {
 		msg = fmt.Sprintf(format, args...)
 	}
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Fprintf(os.Stderr, "[%s] %s: %s\n", timestamp, level, msg)
+	l.logger.Log(ctx, slog.Level(level), msg)
+}
+
+// logAttrsKey is the context key of WithLogAttrs
+type logAttrsKey struct{}
+
+// WithLogAttrs returns a context whose log records carry the given
+// attributes (key-value pairs or slog.Attr), after those already in ctx.
+// The installer uses it to tag records with tool, phase and hook.
+func WithLogAttrs(ctx context.Context, args ...any) context.Context {
+	attrs := append([]slog.Attr(nil), LogAttrs(ctx)...)
+	for _, a := range slog.Group("", args...).Value.Group() {
+		attrs = replaceAttr(attrs, a)
+	}
+	return context.WithValue(ctx, logAttrsKey{}, attrs)
+}
+
+// LogAttrs returns the log attributes of ctx
+func LogAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// replaceAttr sets a in attrs, replacing an attribute with the same key
+func replaceAttr(attrs []slog.Attr, a slog.Attr) []slog.Attr {
+	for i := range attrs {
+		if attrs[i].Key == a.Key {
+			attrs[i] = a
+			return attrs
+		}
+	}
+	return append(attrs, a)
+}
+
+// contextHandler adds the attributes of WithLogAttrs to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := LogAttrs(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// fanoutHandler sends each record to several handlers
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, handler := range h {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithGroup(name)
+	}
+	return out
+}
+
+// textHandler writes records as "[2006-01-02 15:04:05] LEVEL: message key=value"
+type textHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	attrs  string // preformatted attributes of WithAttrs
+	prefix string // group prefix of WithGroup, e.g. "hook."
+}
+
+func newTextHandler(w io.Writer, level slog.Leveler) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	fmt.Fprintf(&b, "[%s] %s: %s", t.Format("2006-01-02 15:04:05"), r.Level, r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+// appendAttr writes " key=value", quoting values with spaces
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, groupPrefix, ga)
+		}
+		return
+	}
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
 }
 
 // Global logger instance
@@ -72,6 +332,22 @@ var defaultLogger *Logger
 // InitLogger initializes the global logger
 func InitLogger(debug bool) {
 	defaultLogger = NewLogger(debug)
+	slog.SetDefault(defaultLogger.logger)
+}
+
+// InitLoggerWithOptions initializes the global logger with options.
+// The previous global logger's file, if any, is closed.
+func InitLoggerWithOptions(opts LoggerOptions) error {
+	logger, err := NewLoggerWithOptions(opts)
+	if err != nil {
+		return err
+	}
+	if defaultLogger != nil {
+		defaultLogger.Close()
+	}
+	defaultLogger = logger
+	slog.SetDefault(logger.logger)
+	return nil
 }
 
 // GetLogger returns the global logger
@@ -82,6 +358,14 @@ func GetLogger() *Logger {
 	return defaultLogger
 }
 
+// CloseLogger closes the global logger's file, if any
+func CloseLogger() error {
+	if defaultLogger == nil {
+		return nil
+	}
+	return defaultLogger.Close()
+}
+
 // Info logs an info message using the global logger
 func Info(format string, args ...interface{}) {
 	GetLogger().LogInfo(format, args...)
@@ -101,3 +385,23 @@ func Error(format string, args ...interface{}) {
 func Debug(format string, args ...interface{}) {
 	GetLogger().LogDebug(format, args...)
 }
+
+// InfoContext logs an info message with the attributes of ctx
+func InfoContext(ctx context.Context, format string, args ...interface{}) {
+	GetLogger().log(ctx, LogLevelInfo, format, args...)
+}
+
+// WarnContext logs a warning message with the attributes of ctx
+func WarnContext(ctx context.Context, format string, args ...interface{}) {
+	GetLogger().log(ctx, LogLevelWarn, format, args...)
+}
+
+// ErrorContext logs an error message with the attributes of ctx
+func ErrorContext(ctx context.Context, format string, args ...interface{}) {
+	GetLogger().log(ctx, LogLevelError, format, args...)
+}
+
+// DebugContext logs a debug message with the attributes of ctx
+func DebugContext(ctx context.Context, format string, args ...interface{}) {
+	GetLogger().log(ctx, LogLevelDebug, format, args...)
+}
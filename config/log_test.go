@@ -1,6 +1,12 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -60,3 +66,92 @@ func TestLogger_DefaultLogger(t *testing.T) {
 	// Should not panic
 	logger.LogInfo("test")
 }
+
+func TestLogger_LevelOrdering(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLoggerWithOptions(LoggerOptions{Level: LogLevelInfo, Output: &buf})
+	if err != nil {
+		t.Fatalf("NewLoggerWithOptions() error: %v", err)
+	}
+
+	logger.LogDebug("debug")
+	logger.LogInfo("info %d", 1)
+	logger.LogWarn("warn")
+	logger.LogError("error")
+
+	out := buf.String()
+	if strings.Contains(out, "DEBUG") {
+		t.Errorf("Expected debug to be suppressed:\n%s", out)
+	}
+	for _, want := range []string{"INFO: info 1\n", "WARN: warn\n", "ERROR: error\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// Debug level shows everything, including info and warnings
+	buf.Reset()
+	logger, _ = NewLoggerWithOptions(LoggerOptions{Level: LogLevelDebug, Output: &buf})
+	logger.LogDebug("debug")
+	logger.LogInfo("info")
+	logger.LogWarn("warn")
+	if strings.Count(buf.String(), "\n") != 3 {
+		t.Errorf("Expected 3 lines at debug level:\n%s", buf.String())
+	}
+}
+
+func TestLogger_ContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := NewLoggerWithOptions(LoggerOptions{Level: LogLevelDebug, Output: &buf})
+
+	ctx := WithLogAttrs(context.Background(), "tool", "jq", "phase", "install")
+	ctx = WithLogAttrs(ctx, "phase", "postinstall", "hook", "jq --version")
+	logger.log(ctx, LogLevelInfo, "hook finished")
+
+	expected := `INFO: hook finished tool=jq phase=postinstall hook="jq --version"` + "\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestLogger_JSONAndFile(t *testing.T) {
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "logs", "run.jsonl")
+	logger, err := NewLoggerWithOptions(LoggerOptions{Level: LogLevelInfo, Format: LogFormatJSON, Output: &buf, File: path})
+	if err != nil {
+		t.Fatalf("NewLoggerWithOptions() error: %v", err)
+	}
+
+	ctx := WithLogAttrs(context.Background(), "tool", "jq")
+	logger.log(ctx, LogLevelDebug, "only in the file")
+	logger.log(ctx, LogLevelWarn, "in both")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Console output is not one JSON record: %v\n%s", err, buf.String())
+	}
+	if record["msg"] != "in both" || record["level"] != "WARN" || record["tool"] != "jq" {
+		t.Errorf("Unexpected record %v", record)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"level":"DEBUG"`) || !strings.Contains(lines[0], `"tool":"jq"`) {
+		t.Errorf("Unexpected log file:\n%s", data)
+	}
+}
+
+func TestParseLogFormat(t *testing.T) {
+	if f, err := ParseLogFormat("json"); err != nil || f != LogFormatJSON {
+		t.Errorf("ParseLogFormat(json) = %q, %v", f, err)
+	}
+	if _, err := ParseLogFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
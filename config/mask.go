@@ -0,0 +1,86 @@
+package config
+
+import (
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Masked replaces secret values in logs, reports and step logs
+const Masked = "[MASKED]"
+
+// SecretSuffixes are the endings of the names of environment variables
+// holding secrets (GITHUB_TOKEN, AWS_SECRET, DB_PASSWORD, API_KEY, ...)
+var SecretSuffixes = []string{"TOKEN", "SECRET", "PASSWORD", "_KEY"}
+
+// minSecretLength is the shortest value masked; very short values would
+// mask unrelated output
+const minSecretLength = 8
+
+// IsSecretName reports whether an environment variable holds a secret,
+// by its name (see SecretSuffixes)
+func IsSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	return slices.ContainsFunc(SecretSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(upper, suffix)
+	})
+}
+
+// SecretValues returns the values of the secret variables of environ (KEY=value
+// pairs), longest first. Values shorter than 8 characters are left out.
+func SecretValues(environ []string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || len(value) < minSecretLength || seen[value] || !IsSecretName(name) {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	sortLongestFirst(values)
+	return values
+}
+
+// sortLongestFirst sorts secrets so that one containing another is fully masked
+func sortLongestFirst(secrets []string) {
+	sort.SliceStable(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// NewMasker returns a replacer of each secret with Masked, the longest first
+func NewMasker(secrets []string) *strings.Replacer {
+	secrets = slices.Clone(secrets)
+	sortLongestFirst(secrets)
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, s := range secrets {
+		pairs = append(pairs, s, Masked)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// maskingWriter replaces secret values in everything written through it
+type maskingWriter struct {
+	w        io.Writer
+	replacer *strings.Replacer
+}
+
+// NewMaskingWriter returns a writer masking secrets in what it writes to w;
+// w itself without secrets
+func NewMaskingWriter(w io.Writer, secrets []string) io.Writer {
+	if len(secrets) == 0 {
+		return w
+	}
+	return &maskingWriter{w: w, replacer: NewMasker(secrets)}
+}
+
+// Write masks p and writes it. It reports len(p) on success, as callers
+// expect, although the masked output may be shorter. A secret split across
+// two writes is not masked; loggers and observers write whole lines.
+func (m *maskingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(m.w, m.replacer.Replace(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestSecretValues(t *testing.T) {
+	environ := []string{
+		"GH_TOKEN=ghp_abcdefgh",
+		"MISE_GITHUB_TOKEN=ghp_abcdefgh",
+		"GITLAB_TOKEN=glpat-0123456789",
+		"AWS_SECRET=aws-secret-value",
+		"DB_PASSWORD=hunter2hunter2",
+		"API_KEY=key-0123456789abcdef",
+		"SHORT_TOKEN=abc",
+		"KEYBOARD=us-international",
+		"HOME=/home/user",
+	}
+	expected := []string{"key-0123456789abcdef", "glpat-0123456789", "aws-secret-value", "hunter2hunter2", "ghp_abcdefgh"}
+	if got := SecretValues(environ); !slices.Equal(got, expected) {
+		t.Errorf("SecretValues() = %v, expected %v", got, expected)
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"GITHUB_TOKEN":          true,
+		"npm_token":             true,
+		"AWS_SECRET":            true,
+		"PGPASSWORD":            true,
+		"STRIPE_API_KEY":        true,
+		"MONKEY":                false,
+		"GITHUB_TOKEN_ENDPOINT": false,
+		"PATH":                  false,
+	} {
+		if got := IsSecretName(name); got != expected {
+			t.Errorf("IsSecretName(%s) = %v, expected %v", name, got, expected)
+		}
+	}
+}
+
+func TestNewMaskingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	// A secret containing another is masked whole, whatever the order given
+	w := NewMaskingWriter(&buf, []string{"abcdefgh", "abcdefgh-long"})
+	if _, err := w.Write([]byte("a abcdefgh-long b abcdefgh\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "a [MASKED] b [MASKED]\n" {
+		t.Errorf("Expected both secrets masked, got %q", got)
+	}
+}
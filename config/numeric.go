@@ -0,0 +1,495 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// NumericVersion records a version written as a number instead of a string
+type NumericVersion struct {
+	// Tool is the tool the version belongs to
+	Tool string
+
+	// Path is the dotted key path of the value (e.g. "tools.go.version")
+	Path string
+
+	// Text is the literal as written in the source (e.g. "1.20")
+	Text string
+
+	// Line and Column locate the literal (both 1-based)
+	Line   int
+	Column int
+}
+
+// FindNumericVersions locates every tool version written as a number literal
+func FindNumericVersions(data []byte, format string) ([]NumericVersion, error) {
+	var (
+		found []NumericVersion
+		err   error
+	)
+
+	switch strings.ToLower(format) {
+	case "json":
+		found, err = findNumericVersionsJSON(data)
+	case "yaml", "yml":
+		found, err = findNumericVersionsYAML(data)
+	case "toml":
+		found, err = findNumericVersionsTOML(data)
+	case "cue":
+		found, err = findNumericVersionsCUE(data)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Line != found[j].Line {
+			return found[i].Line < found[j].Line
+		}
+		return found[i].Column < found[j].Column
+	})
+	return found, nil
+}
+
+// newNumericVersion builds a NumericVersion if path names a tool version
+func newNumericVersion(path []string, text string, line, column int) (NumericVersion, bool) {
+	if len(path) < 3 || path[0] != "tools" || path[len(path)-1] != "version" {
+		return NumericVersion{}, false
+	}
+	return NumericVersion{
+		Tool:   path[1],
+		Path:   strings.Join(path, "."),
+		Text:   text,
+		Line:   line,
+		Column: column,
+	}, true
+}
+
+// findNumericVersionsYAML walks the YAML node tree
+func findNumericVersionsYAML(data []byte) ([]NumericVersion, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	var found []NumericVersion
+	var walk func(node *yaml.Node, path []string)
+	walk = func(node *yaml.Node, path []string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], append(path, node.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, append(path, strconv.Itoa(i)))
+			}
+		case yaml.ScalarNode:
+			if node.Tag != "!!int" && node.Tag != "!!float" {
+				return
+			}
+			if nv, ok := newNumericVersion(path, node.Value, node.Line, node.Column); ok {
+				found = append(found, nv)
+			}
+		}
+	}
+	walk(&root, nil)
+
+	return found, nil
+}
+
+// jsonFrame tracks one level of nesting while streaming JSON tokens
+type jsonFrame struct {
+	object bool
+	key    string
+	index  int
+	isKey  bool
+}
+
+// findNumericVersionsJSON streams JSON tokens, tracking the key path
+func findNumericVersionsJSON(data []byte) ([]NumericVersion, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var (
+		found []NumericVersion
+		stack []*jsonFrame
+	)
+
+	currentPath := func() []string {
+		path := make([]string, 0, len(stack))
+		for _, f := range stack {
+			if f.object {
+				path = append(path, f.key)
+			} else {
+				path = append(path, strconv.Itoa(f.index))
+			}
+		}
+		return path
+	}
+
+	// valueDone advances the enclosing frame after a complete value
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.isKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+
+		// Object keys
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if key, ok := tok.(string); ok && top.object && top.isKey {
+				top.key = key
+				top.isKey = false
+				continue
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '{':
+				stack = append(stack, &jsonFrame{object: true, isKey: true})
+			case '[':
+				stack = append(stack, &jsonFrame{})
+			default:
+				stack = stack[:len(stack)-1]
+				valueDone()
+			}
+		case json.Number:
+			text := v.String()
+			start := int(dec.InputOffset()) - len(text)
+			line, column := offsetToLineColumn(data, start)
+			if nv, ok := newNumericVersion(currentPath(), text, line, column); ok {
+				found = append(found, nv)
+			}
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+
+	return found, nil
+}
+
+// offsetToLineColumn converts a byte offset into a 1-based line and column
+func offsetToLineColumn(data []byte, offset int) (int, int) {
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	return line, offset - lineStart + 1
+}
+
+var (
+	tomlTableRe   = regexp.MustCompile(`^\s*\[(\[?)\s*([^\[\]]+?)\s*\]\]?\s*(#.*)?$`)
+	tomlVersionRe = regexp.MustCompile(`^(\s*)((?:[\w-]+\.)*)version\s*=\s*([-+]?[0-9][0-9_.eE+-]*)\s*(#.*)?$`)
+	tomlInlineRe  = regexp.MustCompile(`^\s*((?:[\w-]+\.)*[\w-]+)\s*=\s*\{.*\bversion\s*=\s*([-+]?[0-9][0-9_.eE+-]*)\s*[,}]`)
+	tomlArrayRe   = regexp.MustCompile(`^\s*((?:[\w-]+\.)*[\w-]+)\s*=\s*\[`)
+	tomlElemRe    = regexp.MustCompile(`\{[^{}]*\}`)
+	tomlElemVerRe = regexp.MustCompile(`\bversion\s*=\s*([-+]?[0-9][0-9_.eE+-]*)\s*[,}]`)
+)
+
+// findNumericVersionsTOML scans TOML line by line.
+// The TOML decoder does not expose source positions, so tables, dotted keys
+// and single-line inline tables are tracked here; multi-line strings are skipped.
+// Float versions the scan misses are reported as an error, since the decoder
+// would otherwise silently turn them into a different version.
+func findNumericVersionsTOML(data []byte) ([]NumericVersion, error) {
+	var (
+		found       []NumericVersion
+		table       []string
+		arrayCounts = make(map[string]int)
+		inString    string
+	)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		// Skip the body of multi-line strings
+		if inString != "" {
+			if strings.Count(line, inString)%2 == 1 {
+				inString = ""
+			}
+			continue
+		}
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.Count(line, delim)%2 == 1 {
+				inString = delim
+			}
+		}
+
+		if m := tomlTableRe.FindStringSubmatch(line); m != nil {
+			table = strings.Split(m[2], ".")
+			for j := range table {
+				table[j] = strings.Trim(strings.TrimSpace(table[j]), `"'`)
+			}
+			if m[1] == "[" {
+				// Array of tables: index each occurrence
+				key := strings.Join(table, ".")
+				table = append(table, strconv.Itoa(arrayCounts[key]))
+				arrayCounts[key]++
+			}
+			continue
+		}
+
+		if m := tomlVersionRe.FindStringSubmatchIndex(line); m != nil {
+			path := append([]string{}, table...)
+			if prefix := strings.TrimSuffix(line[m[4]:m[5]], "."); prefix != "" {
+				path = append(path, strings.Split(prefix, ".")...)
+			}
+			path = append(path, "version")
+			if nv, ok := newNumericVersion(path, line[m[6]:m[7]], i+1, m[6]+1); ok {
+				found = append(found, nv)
+			}
+			continue
+		}
+
+		// Single-line arrays of inline tables: versions = [{version = 3.11}, ...]
+		if m := tomlArrayRe.FindStringSubmatchIndex(line); m != nil {
+			key := strings.Split(line[m[2]:m[3]], ".")
+			for idx, elem := range tomlElemRe.FindAllStringIndex(line[m[1]:], -1) {
+				start := m[1] + elem[0]
+				vm := tomlElemVerRe.FindStringSubmatchIndex(line[start : m[1]+elem[1]])
+				if vm == nil {
+					continue
+				}
+				path := append(append([]string{}, table...), key...)
+				path = append(path, strconv.Itoa(idx), "version")
+				if nv, ok := newNumericVersion(path, line[start+vm[2]:start+vm[3]], i+1, start+vm[2]+1); ok {
+					found = append(found, nv)
+				}
+			}
+			continue
+		}
+
+		if m := tomlInlineRe.FindStringSubmatchIndex(line); m != nil {
+			path := append([]string{}, table...)
+			path = append(path, strings.Split(line[m[2]:m[3]], ".")...)
+			path = append(path, "version")
+			if nv, ok := newNumericVersion(path, line[m[4]:m[5]], i+1, m[4]+1); ok {
+				found = append(found, nv)
+			}
+		}
+	}
+
+	if err := checkNumericVersionsTOML(data, found); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// checkNumericVersionsTOML decodes data and returns an error for each float
+// tool version that is not among found
+func checkNumericVersionsTOML(data []byte, found []NumericVersion) error {
+	var raw struct {
+		Tools map[string]map[string]interface{} `toml:"tools"`
+	}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return fmt.Errorf("failed to parse TOML: %w", err)
+	}
+
+	located := make(map[string]bool, len(found))
+	for _, nv := range found {
+		located[nv.Path] = true
+	}
+
+	var missed []string
+	check := func(path string, value interface{}) {
+		if _, ok := value.(float64); ok && !located[path] {
+			missed = append(missed, path)
+		}
+	}
+	for name, tool := range raw.Tools {
+		prefix := "tools." + name + "."
+		check(prefix+"version", tool["version"])
+		// Arrays of tables decode as []map, arrays of inline tables as []interface{}
+		switch versions := tool["versions"].(type) {
+		case []map[string]interface{}:
+			for i, v := range versions {
+				check(prefix+"versions."+strconv.Itoa(i)+".version", v["version"])
+			}
+		case []interface{}:
+			for i, v := range versions {
+				if v, ok := v.(map[string]interface{}); ok {
+					check(prefix+"versions."+strconv.Itoa(i)+".version", v["version"])
+				}
+			}
+		}
+	}
+	if len(missed) > 0 {
+		sort.Strings(missed)
+		return fmt.Errorf("could not locate the numeric version literal of %s; quote the version", strings.Join(missed, ", "))
+	}
+	return nil
+}
+
+// findNumericVersionsCUE walks the CUE syntax tree
+func findNumericVersionsCUE(data []byte) ([]NumericVersion, error) {
+	file, err := parser.ParseFile("config.cue", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CUE: %w", err)
+	}
+
+	var (
+		found     []NumericVersion
+		path      []string
+		listIndex = make(map[ast.Node]int)
+	)
+	ast.Walk(file, func(n ast.Node) bool {
+		if list, ok := n.(*ast.ListLit); ok {
+			for i, elem := range list.Elts {
+				listIndex[elem] = i
+			}
+		}
+		if idx, ok := listIndex[n]; ok {
+			path = append(path, strconv.Itoa(idx))
+		}
+
+		field, ok := n.(*ast.Field)
+		if !ok {
+			return true
+		}
+		name, _, _ := ast.LabelName(field.Label)
+		path = append(path, name)
+
+		if lit, ok := field.Value.(*ast.BasicLit); ok && (lit.Kind == token.INT || lit.Kind == token.FLOAT) {
+			// Tool paths may be nested inside a wrapper struct (e.g. MiseSeqConfig: tools: ...)
+			for i, p := range path {
+				if p != "tools" {
+					continue
+				}
+				pos := lit.Pos()
+				if nv, ok := newNumericVersion(path[i:], lit.Value, pos.Line(), pos.Column()); ok {
+					found = append(found, nv)
+				}
+				break
+			}
+		}
+		return true
+	}, func(n ast.Node) {
+		if _, ok := n.(*ast.Field); ok {
+			path = path[:len(path)-1]
+		}
+		if _, ok := listIndex[n]; ok {
+			path = path[:len(path)-1]
+		}
+	})
+
+	return found, nil
+}
+
+// applyNumericVersions restores the source text of numeric versions on cfg
+func applyNumericVersions(cfg *Config, found []NumericVersion) {
+	for _, nv := range found {
+		tool, ok := cfg.Tools[nv.Tool]
+		if !ok {
+			continue
+		}
+
+		prefix := "tools." + nv.Tool + "."
+		switch rest := strings.TrimPrefix(nv.Path, prefix); {
+		case rest == "version":
+			tool.Version = Version(nv.Text)
+		case strings.HasPrefix(rest, "versions.") && strings.HasSuffix(rest, ".version"):
+			idx, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rest, "versions."), ".version"))
+			if err != nil || idx >= len(tool.Versions) {
+				continue
+			}
+			tool.Versions[idx].Version = Version(nv.Text)
+		}
+		cfg.Tools[nv.Tool] = tool
+	}
+}
+
+// QuoteNumericVersions rewrites numeric tool versions in data as quoted strings,
+// leaving the rest of the file untouched
+func QuoteNumericVersions(data []byte, format string) ([]byte, []NumericVersion, error) {
+	found, err := FindNumericVersions(data, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(found) == 0 {
+		return data, nil, nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+
+	// Apply edits from the end so earlier columns stay valid
+	for i := len(found) - 1; i >= 0; i-- {
+		nv := found[i]
+		line := lines[nv.Line-1]
+		start := nv.Column - 1
+		end := start + len(nv.Text)
+		if end > len(line) || line[start:end] != nv.Text {
+			return nil, nil, fmt.Errorf("line %d: version literal %s not found at column %d", nv.Line, nv.Text, nv.Column)
+		}
+		lines[nv.Line-1] = line[:start] + strconv.Quote(nv.Text) + line[end:]
+	}
+
+	return []byte(strings.Join(lines, "\n")), found, nil
+}
+
+// FormatFile rewrites numeric tool versions in a config file as quoted strings.
+// It returns the versions that were rewritten; the file is only written if
+// something changed.
+func FormatFile(path string) ([]NumericVersion, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file not found: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	formatted, found, err := QuoteNumericVersions(data, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(path, formatted, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return found, nil
+}
@@ -23,6 +23,12 @@ func ParseJSON(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
+	numericVersions, err := FindNumericVersions(data, "json")
+	if err != nil {
+		return nil, err
+	}
+	cfg.numericVersions = numericVersions
+
 	return &cfg, nil
 }
 
@@ -38,6 +44,12 @@ func ParseYAML(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	numericVersions, err := FindNumericVersions(data, "yaml")
+	if err != nil {
+		return nil, err
+	}
+	cfg.numericVersions = numericVersions
+
 	return &cfg, nil
 }
 
@@ -53,6 +65,14 @@ func ParseTOML(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse TOML: %w", err)
 	}
 
+	// The TOML decoder turns 1.20 into 1.2; restore the literal text
+	numericVersions, err := FindNumericVersions(data, "toml")
+	if err != nil {
+		return nil, err
+	}
+	cfg.numericVersions = numericVersions
+	applyNumericVersions(&cfg, cfg.numericVersions)
+
 	return &cfg, nil
 }
 
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Platform describes the machine tools are installed on
+type Platform struct {
+	OS     string // GOOS (linux, darwin, windows, ...)
+	Arch   string // GOARCH (amd64, arm64, ...)
+	Libc   string // glibc or musl on Linux, empty elsewhere
+	Distro string // ID from /etc/os-release (ubuntu, alpine, ...)
+}
+
+// String formats the platform as os/arch[/libc]
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Arch
+	if p.Libc != "" {
+		s += "/" + p.Libc
+	}
+	return s
+}
+
+// Condition selects platforms by os, arch, libc and distro.
+// Each list is ORed; lists are ANDed; an empty list matches anything.
+type Condition struct {
+	OS     []string `json:"os,omitempty" yaml:"os,omitempty" toml:"os,omitempty"`
+	Arch   []string `json:"arch,omitempty" yaml:"arch,omitempty" toml:"arch,omitempty"`
+	Libc   []string `json:"libc,omitempty" yaml:"libc,omitempty" toml:"libc,omitempty"`
+	Distro []string `json:"distro,omitempty" yaml:"distro,omitempty" toml:"distro,omitempty"`
+}
+
+// archAliases maps uname-style architecture names to GOARCH
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x64":     "amd64",
+	"aarch64": "arm64",
+	"i386":    "386",
+	"i686":    "386",
+	"armv7l":  "arm",
+}
+
+// normalizeArch converts an architecture name to GOARCH
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(arch)
+	if alias, ok := archAliases[arch]; ok {
+		return alias
+	}
+	return arch
+}
+
+// DetectPlatform detects the current platform
+func DetectPlatform() Platform {
+	p := Platform{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+	if p.OS == "linux" {
+		p.Libc = detectLibc()
+		p.Distro = detectDistro("/etc/os-release")
+	}
+	return p
+}
+
+// detectLibc reports musl if the musl dynamic loader is present, glibc otherwise
+func detectLibc() string {
+	for _, pattern := range []string{"/lib/ld-musl-*.so.1", "/lib/libc.musl-*.so.1", "/usr/lib/ld-musl-*.so.1"} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return "musl"
+		}
+	}
+	return "glibc"
+}
+
+// detectDistro reads the ID field of an os-release file
+func detectDistro(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, "ID="); ok {
+			return strings.ToLower(strings.Trim(value, `"'`))
+		}
+	}
+	return ""
+}
+
+// matchAny reports whether value is in list (case-insensitive, "*" matches anything)
+func matchAny(list []string, value string, normalize func(string) string) bool {
+	for _, item := range list {
+		if item == "*" || normalize(item) == normalize(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the condition matches p, with a reason when it does not
+func (c *Condition) Match(p Platform) (bool, string) {
+	if c == nil {
+		return true, ""
+	}
+
+	checks := []struct {
+		field     string
+		list      []string
+		value     string
+		normalize func(string) string
+	}{
+		{"os", c.OS, p.OS, strings.ToLower},
+		{"arch", c.Arch, p.Arch, normalizeArch},
+		{"libc", c.Libc, p.Libc, strings.ToLower},
+		{"distro", c.Distro, p.Distro, strings.ToLower},
+	}
+	for _, check := range checks {
+		if len(check.list) == 0 {
+			continue
+		}
+		if !matchAny(check.list, check.value, check.normalize) {
+			value := check.value
+			if value == "" {
+				value = "unknown"
+			}
+			return false, fmt.Sprintf("if.%s %v does not match %s", check.field, check.list, value)
+		}
+	}
+	return true, ""
+}
+
+// matchPlatformSpec matches a platform shorthand "os[/arch[/libc]]" against p
+func matchPlatformSpec(spec string, p Platform) bool {
+	parts := strings.Split(spec, "/")
+	cond := &Condition{OS: []string{parts[0]}}
+	if len(parts) > 1 {
+		cond.Arch = []string{parts[1]}
+	}
+	if len(parts) > 2 {
+		cond.Libc = []string{parts[2]}
+	}
+	ok, _ := cond.Match(p)
+	return ok
+}
+
+// matchSelectors evaluates platforms and if selectors together
+func matchSelectors(platforms []string, cond *Condition, p Platform) (bool, string) {
+	if len(platforms) > 0 {
+		matched := false
+		for _, spec := range platforms {
+			if matchPlatformSpec(spec, p) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, fmt.Sprintf("platforms %v do not match %s", platforms, p)
+		}
+	}
+	return cond.Match(p)
+}
+
+// MatchPlatform reports whether the tool applies to p, with a reason when it does not
+func (t Tool) MatchPlatform(p Platform) (bool, string) {
+	return matchSelectors(t.Platforms, t.If, p)
+}
+
+// MatchPlatform reports whether the hook applies to p, with a reason when it does not
+func (h Hook) MatchPlatform(p Platform) (bool, string) {
+	return matchSelectors(h.Platforms, h.If, p)
+}
+
+// Exclusion records a tool left out of a run and why
+type Exclusion struct {
+	Tool   string `json:"tool" yaml:"tool"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// filterHooks returns the hooks that apply to p
+func filterHooks(hooks []Hook, p Platform) []Hook {
+	if hooks == nil {
+		return nil
+	}
+	filtered := make([]Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		if ok, _ := hook.MatchPlatform(p); ok {
+			filtered = append(filtered, hook)
+		}
+	}
+	return filtered
+}
+
+// FilterForPlatform returns a copy of cfg without the tools and hooks that do not
+// apply to p. Tools depending on an excluded tool are excluded as well.
+// The excluded tools are returned with the reason, in config order.
+func FilterForPlatform(cfg *Config, p Platform) (*Config, []Exclusion) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	reasons := make(map[string]string)
+	for name, tool := range cfg.Tools {
+		if ok, reason := tool.MatchPlatform(p); !ok {
+			reasons[name] = reason
+		}
+	}
+
+	filtered, exclusions := excludeTools(cfg, reasons)
+	for name, tool := range filtered.Tools {
+		tool.Preinstall = filterHooks(tool.Preinstall, p)
+		tool.Postinstall = filterHooks(tool.Postinstall, p)
+		filtered.Tools[name] = tool
+	}
+
+	if cfg.Defaults != nil {
+		defaults := *cfg.Defaults
+		defaults.Preinstall = filterHooks(defaults.Preinstall, p)
+		defaults.Postinstall = filterHooks(defaults.Postinstall, p)
+		filtered.Defaults = &defaults
+	}
+
+	return filtered, exclusions
+}
+
+// FilterDisabled returns a copy of cfg without the tools marked enabled: false.
+// Tools depending on a disabled tool are excluded as well.
+func FilterDisabled(cfg *Config) (*Config, []Exclusion) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	reasons := make(map[string]string)
+	for name, tool := range cfg.Tools {
+		if !tool.IsEnabled() {
+			reasons[name] = "disabled"
+		}
+	}
+	return excludeTools(cfg, reasons)
+}
+
+// excludeTools returns a copy of cfg without the tools in reasons, which is
+// extended with the tools depending on them
+func excludeTools(cfg *Config, reasons map[string]string) (*Config, []Exclusion) {
+	filtered := *cfg
+
+	// Propagate exclusions to dependents until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for name, tool := range cfg.Tools {
+			if _, excluded := reasons[name]; excluded {
+				continue
+			}
+			for _, dep := range tool.GetDependencies() {
+				if _, excluded := reasons[dep.Name]; excluded {
+					reasons[name] = fmt.Sprintf("depends on excluded tool '%s'", dep.Name)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	if cfg.Tools != nil {
+		filtered.Tools = make(map[string]Tool, len(cfg.Tools))
+		for name, tool := range cfg.Tools {
+			if _, excluded := reasons[name]; !excluded {
+				filtered.Tools[name] = tool
+			}
+		}
+	}
+
+	if cfg.ToolsOrder != nil {
+		filtered.ToolsOrder = make([]string, 0, len(cfg.ToolsOrder))
+		for _, name := range cfg.ToolsOrder {
+			if _, excluded := reasons[name]; !excluded {
+				filtered.ToolsOrder = append(filtered.ToolsOrder, name)
+			}
+		}
+	}
+
+	return &filtered, sortedExclusions(cfg, reasons)
+}
+
+// sortedExclusions orders exclusions by tools_order, then by name
+func sortedExclusions(cfg *Config, reasons map[string]string) []Exclusion {
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	var exclusions []Exclusion
+	seen := make(map[string]bool)
+	for _, name := range cfg.ToolsOrder {
+		if reason, ok := reasons[name]; ok && !seen[name] {
+			exclusions = append(exclusions, Exclusion{Tool: name, Reason: reason})
+			seen[name] = true
+		}
+	}
+
+	var rest []string
+	for name := range reasons {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		exclusions = append(exclusions, Exclusion{Tool: name, Reason: reasons[name]})
+	}
+	return exclusions
+}
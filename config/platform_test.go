@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCondition_Match(t *testing.T) {
+	linuxArm := Platform{OS: "linux", Arch: "arm64", Libc: "glibc", Distro: "ubuntu"}
+
+	tests := []struct {
+		name     string
+		cond     *Condition
+		expected bool
+	}{
+		{"nil condition", nil, true},
+		{"empty condition", &Condition{}, true},
+		{"os match", &Condition{OS: []string{"linux"}}, true},
+		{"os mismatch", &Condition{OS: []string{"darwin"}}, false},
+		{"arch alias", &Condition{Arch: []string{"aarch64"}}, true},
+		{"arch mismatch", &Condition{Arch: []string{"x86_64"}}, false},
+		{"libc mismatch", &Condition{Libc: []string{"musl"}}, false},
+		{"distro match", &Condition{Distro: []string{"debian", "ubuntu"}}, true},
+		{"all fields", &Condition{OS: []string{"linux"}, Arch: []string{"arm64"}, Libc: []string{"glibc"}, Distro: []string{"ubuntu"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := tt.cond.Match(linuxArm)
+			if ok != tt.expected {
+				t.Errorf("Match() = %v (%s), expected %v", ok, reason, tt.expected)
+			}
+			if !ok && reason == "" {
+				t.Error("Expected a reason when not matching")
+			}
+		})
+	}
+}
+
+func TestTool_MatchPlatform(t *testing.T) {
+	musl := Platform{OS: "linux", Arch: "amd64", Libc: "musl", Distro: "alpine"}
+
+	tests := []struct {
+		name     string
+		tool     Tool
+		expected bool
+	}{
+		{"no selectors", Tool{}, true},
+		{"os only", Tool{Platforms: []string{"linux"}}, true},
+		{"os and arch", Tool{Platforms: []string{"linux/amd64"}}, true},
+		{"libc mismatch", Tool{Platforms: []string{"linux/amd64/glibc"}}, false},
+		{"any of several", Tool{Platforms: []string{"darwin", "linux/*/musl"}}, true},
+		{"platforms and if", Tool{Platforms: []string{"linux"}, If: &Condition{Distro: []string{"ubuntu"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, reason := tt.tool.MatchPlatform(musl); ok != tt.expected {
+				t.Errorf("MatchPlatform() = %v (%s), expected %v", ok, reason, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterForPlatform(t *testing.T) {
+	cfg := &Config{
+		ToolsOrder: []string{"node", "pnpm", "jq", "mas"},
+		Tools: map[string]Tool{
+			"node": {Platforms: []string{"linux/amd64/glibc"}},
+			"pnpm": {Depends: []string{"node"}},
+			"jq": {
+				Postinstall: []Hook{
+					{Run: "echo always"},
+					{Run: "echo arm only", If: &Condition{Arch: []string{"arm64"}}},
+				},
+			},
+			"mas": {If: &Condition{OS: []string{"darwin"}}},
+		},
+		Defaults: &Defaults{
+			Preinstall: []Hook{{Run: "apk add curl", If: &Condition{Distro: []string{"alpine"}}}},
+		},
+	}
+
+	platform := Platform{OS: "linux", Arch: "amd64", Libc: "musl", Distro: "alpine"}
+	filtered, excluded := FilterForPlatform(cfg, platform)
+
+	if len(filtered.Tools) != 1 {
+		t.Fatalf("Expected only jq to remain, got %v", filtered.Tools)
+	}
+	if len(filtered.Tools["jq"].Postinstall) != 1 {
+		t.Errorf("Expected arm-only hook to be filtered, got %d hooks", len(filtered.Tools["jq"].Postinstall))
+	}
+	if len(filtered.ToolsOrder) != 1 || filtered.ToolsOrder[0] != "jq" {
+		t.Errorf("Expected tools_order [jq], got %v", filtered.ToolsOrder)
+	}
+	if len(filtered.Defaults.Preinstall) != 1 {
+		t.Errorf("Expected alpine default hook to remain, got %d", len(filtered.Defaults.Preinstall))
+	}
+
+	expected := []string{"node", "pnpm", "mas"}
+	if len(excluded) != len(expected) {
+		t.Fatalf("Expected %d exclusions, got %+v", len(expected), excluded)
+	}
+	for i, name := range expected {
+		if excluded[i].Tool != name {
+			t.Errorf("excluded[%d] = %s, expected %s", i, excluded[i].Tool, name)
+		}
+		if excluded[i].Reason == "" {
+			t.Errorf("Expected a reason for %s", name)
+		}
+	}
+
+	// The original config is left untouched
+	if len(cfg.Tools) != 4 || len(cfg.Tools["jq"].Postinstall) != 2 {
+		t.Error("FilterForPlatform modified the original config")
+	}
+}
+
+func TestFilterDisabled(t *testing.T) {
+	disabled := false
+	cfg := &Config{
+		ToolsOrder: []string{"node", "pnpm", "jq"},
+		Tools: map[string]Tool{
+			"node": {Enabled: &disabled},
+			"pnpm": {Depends: []string{"node"}},
+			"jq":   {},
+		},
+	}
+
+	filtered, excluded := FilterDisabled(cfg)
+	if len(filtered.Tools) != 1 || len(filtered.ToolsOrder) != 1 {
+		t.Fatalf("Expected only jq to remain, got %v (order %v)", filtered.Tools, filtered.ToolsOrder)
+	}
+	if len(excluded) != 2 {
+		t.Fatalf("Expected 2 exclusions, got %+v", excluded)
+	}
+	if excluded[0].Tool != "node" || excluded[0].Reason != "disabled" {
+		t.Errorf("Unexpected exclusion %+v", excluded[0])
+	}
+	if excluded[1].Tool != "pnpm" || excluded[1].Reason != "depends on excluded tool 'node'" {
+		t.Errorf("Unexpected exclusion %+v", excluded[1])
+	}
+}
+
+func TestDetectDistro(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	data := "NAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.20.0\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write os-release: %v", err)
+	}
+
+	if distro := detectDistro(path); distro != "alpine" {
+		t.Errorf("Expected alpine, got %s", distro)
+	}
+	if distro := detectDistro(filepath.Join(t.TempDir(), "missing")); distro != "" {
+		t.Errorf("Expected empty distro for missing file, got %s", distro)
+	}
+}
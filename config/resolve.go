@@ -129,8 +129,9 @@ func (r *ToolResolver) ResolveOrder() ([]string, error) {
 }
 
 // GetToolWithVersion returns the tool name with version (tool@version or tool@latest)
+// For tools with a versions list the default version is used
 func GetToolWithVersion(name string, tool Tool) string {
-	version := tool.Version
+	version := string(tool.DefaultVersion())
 	if version == "" {
 		version = "latest"
 	}
@@ -168,3 +169,29 @@ func ValidateDependencies(cfg *Config) error {
 
 	return nil
 }
+
+// Closure returns the given tools plus everything they transitively depend on
+func (r *ToolResolver) Closure(names []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	queue := append([]string(nil), names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+
+		tool, exists := r.tools[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown tool '%s'", name)
+		}
+		seen[name] = true
+		result = append(result, name)
+
+		for _, dep := range tool.GetDependencies() {
+			queue = append(queue, dep.Name)
+		}
+	}
+	return result, nil
+}
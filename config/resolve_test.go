@@ -223,3 +223,31 @@ func TestGetToolWithVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestToolResolver_Closure(t *testing.T) {
+	tools := map[string]Tool{
+		"nodejs": {Version: "20"},
+		"pnpm":   {Depends: []string{"nodejs@20"}},
+		"turbo":  {Depends: []string{"pnpm"}},
+		"jq":     {},
+	}
+
+	closure, err := NewToolResolver(tools).Closure([]string{"turbo"})
+	if err != nil {
+		t.Fatalf("Closure failed: %v", err)
+	}
+	expected := []string{"turbo", "pnpm", "nodejs"}
+	if len(closure) != len(expected) {
+		t.Fatalf("Closure = %v, expected %v", closure, expected)
+	}
+	for i := range expected {
+		if closure[i] != expected[i] {
+			t.Errorf("Closure = %v, expected %v", closure, expected)
+			break
+		}
+	}
+
+	if _, err := NewToolResolver(tools).Closure([]string{"rg"}); err == nil {
+		t.Error("Expected error for unknown tool")
+	}
+}
@@ -1,16 +1,72 @@
 package miseseq
 
-// Version can be string, float, or empty (defaults to latest)
-#Version: string | float | *"" | "latest"
+// Version can be string, number, or empty (defaults to latest)
+// Numbers are kept as written (1.20 stays "1.20"); 'mise-seq fmt' quotes them
+// Strings containing operators must be valid constraints (e.g. ">=1.20 <1.23", "~3.11", "^18 || ^20")
+#Version: #PlainVersion | #VersionConstraint | number | *"" | "latest"
+
+// Plain version, prefix or alias passed to mise verbatim
+#PlainVersion: =~"^[^<>=~^!,| ]*$"
+
+// Version constraint resolved against mise ls-remote
+// Terms are separated by spaces or commas (AND); groups by "||" (OR)
+_constraintTerm:  "(([<>]=?|!=|[=~^])\\s*)?v?[0-9]+(\\.[0-9]+){0,2}(-[0-9A-Za-z.-]+)?"
+_constraintGroup: "\(_constraintTerm)([\\s,]+\(_constraintTerm))*"
+#VersionConstraint: =~"^\\s*\(_constraintGroup)(\\s*\\|\\|\\s*\(_constraintGroup))*\\s*$"
 
 // Hook trigger timing
 #When: "install" | "update" | "always"
 
-// Hook definition
+// Platform selector: os[/arch[/libc]], e.g. "linux", "linux/arm64", "linux/amd64/musl"
+#PlatformSpec: =~"^[a-z0-9_*]+(/[a-z0-9_*]+(/(glibc|musl|\\*))?)?$"
+
+// Platform condition: each list is ORed, lists are ANDed
+#Condition: {
+  os?:     [...string]
+  arch?:   [...string]
+  libc?:   [...("glibc" | "musl")]
+  distro?: [...string]
+}
+
+// Go duration, e.g. "30s", "5m", "1h30m"
+#Duration: =~"^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+
+// Hook interpreter: a named shell, or a command with {0} for the script file,
+// as a string split on whitespace or a list used verbatim
+#Shell: "sh" | "bash" | "zsh" | "python" | =~"\\{0\\}" | [string, ...string]
+
+// Environment variables for hooks
+#Env: [=~"^[A-Za-z_][A-Za-z0-9_]*$"]: string
+
+// Hook definition: an inline script (run) or a script file relative to the
+// config file (file)
 #Hook: {
-  run:          string
+  run?:         string
+  file?:        string
+  shell?:       #Shell
+  // Working directory; ~ and paths relative to the config file are resolved
+  cwd?:         string
+  // Merged over the defaults and tool env
+  env?:         #Env
   when?:        [...#When]
   description?: string
+  platforms?:   [...#PlatformSpec]
+  if?:          #Condition
+
+  // Kill the hook after this long; it then counts as timed out
+  timeout?:           #Duration
+  // Attempts after the first one when the hook fails or times out
+  retries?:           int & >=0
+  retry_delay?:       #Duration
+  // A failure is a warning and does not fail the tool
+  continue_on_error?: bool
+
+  // Guards: skip the hook if the path exists (creates) or is missing
+  // (removes), or if the command succeeds (unless) or fails (only_if)
+  creates?: string
+  removes?: string
+  unless?:  string
+  only_if?: string
 }
 
 #HookList: [...#Hook]
@@ -19,19 +75,39 @@ package miseseq
 #Defaults: {
   preinstall?:  #HookList
   postinstall?: #HookList
+  env?:         #Env
+}
+
+// One of several versions installed side by side
+// Only the default (or the first entry if none is marked) is activated globally
+#ToolVersion: {
+  version:  #Version
+  default?: bool
 }
 
+// mise tool options (exe, matching, bin_path, postinstall, ...)
+// Passed inline as tool[key=value,...]@version, which mise cannot quote
+#ToolOptions: [=~"^[^,\\[\\]=@\\s]+$"]: (string & =~"^[^,\\[\\]=@\\s]*$") | bool | number
+
 // Tool configuration
 // All fields are optional - defaults are:
 //   version: "latest"
-//   exe: <tool name>
+//   exe: <tool name> (folded into options.exe)
 //   depends: []
 #ToolConfig: {
   version?:    #Version
+  versions?:   [...#ToolVersion]
   exe?:        string
+  options?:    #ToolOptions
   depends?:    [...string]
+  platforms?:  [...#PlatformSpec]
+  if?:         #Condition
+  tags?:       [...#Tag]
+  optional?:   bool
+  enabled?:    bool
   preinstall?:  #HookList
   postinstall?: #HookList
+  env?:         #Env
 }
 
 // NPM settings
@@ -45,6 +121,9 @@ package miseseq
   experimental?: string
 }
 
+// Tag or group name used by --tags / --skip-tags
+#Tag: =~"^[A-Za-z0-9][A-Za-z0-9_.-]*$"
+
 // Main configuration
 #MiseSeqConfig: {
   defaults?:    #Defaults
@@ -54,5 +133,10 @@ package miseseq
     [string]: #ToolConfig
   }
 
+  // Named groups of tools, selectable like tags
+  groups?: {
+    [#Tag]: [...string]
+  }
+
   settings?: #Settings
 }
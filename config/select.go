@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Selection narrows a run down to some of the configured tools.
+// Tags match both tool tags and group names.
+type Selection struct {
+	Tools    []string // tool names given on the command line
+	Tags     []string // only tools with one of these tags or in one of these groups
+	SkipTags []string // drop tools with one of these tags or in one of these groups
+}
+
+// IsEmpty reports whether the selection keeps every tool
+func (s Selection) IsEmpty() bool {
+	return len(s.Tools) == 0 && len(s.Tags) == 0 && len(s.SkipTags) == 0
+}
+
+// HasTag reports whether the tool is tagged with tag
+func (t Tool) HasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if tt == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTag reports whether the named tool has tag or is in the group named tag
+func (c *Config) matchesTag(name, tag string) bool {
+	if c.Tools[name].HasTag(tag) {
+		return true
+	}
+	for _, member := range c.Groups[tag] {
+		if member == name {
+			return true
+		}
+	}
+	return false
+}
+
+// knownTag reports whether tag names a group or is used by any tool
+func (c *Config) knownTag(tag string) bool {
+	if _, ok := c.Groups[tag]; ok {
+		return true
+	}
+	for _, tool := range c.Tools {
+		if tool.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns a copy of cfg holding only the selected tools and the
+// transitive closure of their dependencies. Skipped tags are applied before
+// dependencies are added, so a skipped tool is still installed when a
+// selected tool depends on it.
+func Select(cfg *Config, sel Selection) (*Config, error) {
+	if cfg == nil || sel.IsEmpty() {
+		return cfg, nil
+	}
+
+	for _, tag := range append(append([]string(nil), sel.Tags...), sel.SkipTags...) {
+		if !cfg.knownTag(tag) {
+			return nil, fmt.Errorf("unknown tag or group '%s'", tag)
+		}
+	}
+	for _, name := range sel.Tools {
+		if _, ok := cfg.Tools[name]; !ok {
+			return nil, fmt.Errorf("unknown tool '%s'", name)
+		}
+	}
+
+	// Pick the seed tools, in a stable order
+	names := make([]string, 0, len(cfg.Tools))
+	for name := range cfg.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	selectAll := len(sel.Tools) == 0 && len(sel.Tags) == 0
+	requested := make(map[string]bool)
+	for _, name := range sel.Tools {
+		requested[name] = true
+	}
+
+	var seeds []string
+	for _, name := range names {
+		if !selectAll && !requested[name] && !cfg.matchesAnyTag(name, sel.Tags) {
+			continue
+		}
+		if cfg.matchesAnyTag(name, sel.SkipTags) {
+			continue
+		}
+		seeds = append(seeds, name)
+	}
+
+	closure, err := NewToolResolver(cfg.Tools).Closure(seeds)
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[string]bool, len(closure))
+	for _, name := range closure {
+		keep[name] = true
+	}
+
+	selected := *cfg
+	selected.Tools = make(map[string]Tool, len(keep))
+	for name := range keep {
+		selected.Tools[name] = cfg.Tools[name]
+	}
+	if cfg.ToolsOrder != nil {
+		selected.ToolsOrder = make([]string, 0, len(cfg.ToolsOrder))
+		for _, name := range cfg.ToolsOrder {
+			if keep[name] {
+				selected.ToolsOrder = append(selected.ToolsOrder, name)
+			}
+		}
+	}
+	return &selected, nil
+}
+
+// matchesAnyTag reports whether the named tool matches any of tags
+func (c *Config) matchesAnyTag(name string, tags []string) bool {
+	for _, tag := range tags {
+		if c.matchesTag(name, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGroups checks that group members are configured tools
+func validateGroups(cfg *Config) error {
+	for group, members := range cfg.Groups {
+		for _, member := range members {
+			if _, ok := cfg.Tools[member]; !ok {
+				return fmt.Errorf("group '%s' contains '%s' which is not in tools", group, member)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func selectionConfig() *Config {
+	return &Config{
+		ToolsOrder: []string{"nodejs", "pnpm", "jq", "yq", "terraform"},
+		Tools: map[string]Tool{
+			"nodejs":    {Version: "20", Tags: []string{"runtime"}},
+			"pnpm":      {Depends: []string{"nodejs"}, Tags: []string{"core"}},
+			"jq":        {Tags: []string{"core", "cli"}},
+			"yq":        {Tags: []string{"cli"}},
+			"terraform": {Tags: []string{"infra"}},
+		},
+		Groups: map[string][]string{
+			"ops": {"terraform", "yq"},
+		},
+	}
+}
+
+func selectedNames(cfg *Config) []string {
+	var names []string
+	for name := range cfg.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		sel      Selection
+		expected []string
+	}{
+		{"empty keeps all", Selection{}, []string{"jq", "nodejs", "pnpm", "terraform", "yq"}},
+		{"positional", Selection{Tools: []string{"jq", "yq"}}, []string{"jq", "yq"}},
+		{"pulls in depends", Selection{Tools: []string{"pnpm"}}, []string{"nodejs", "pnpm"}},
+		{"tag", Selection{Tags: []string{"core"}}, []string{"jq", "nodejs", "pnpm"}},
+		{"group", Selection{Tags: []string{"ops"}}, []string{"terraform", "yq"}},
+		{"tools and tags", Selection{Tools: []string{"terraform"}, Tags: []string{"cli"}}, []string{"jq", "terraform", "yq"}},
+		{"skip tag", Selection{SkipTags: []string{"cli"}}, []string{"nodejs", "pnpm", "terraform"}},
+		{"skip group", Selection{Tags: []string{"cli"}, SkipTags: []string{"ops"}}, []string{"jq"}},
+		{"skipped dependency kept", Selection{Tools: []string{"pnpm"}, SkipTags: []string{"runtime"}}, []string{"nodejs", "pnpm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Select(selectionConfig(), tt.sel)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			if got := selectedNames(cfg); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Select(%+v) = %v, expected %v", tt.sel, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelect_ToolsOrder(t *testing.T) {
+	cfg, err := Select(selectionConfig(), Selection{Tags: []string{"core"}})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	expected := []string{"nodejs", "pnpm", "jq"}
+	if !reflect.DeepEqual(cfg.ToolsOrder, expected) {
+		t.Errorf("ToolsOrder = %v, expected %v", cfg.ToolsOrder, expected)
+	}
+}
+
+func TestSelect_Unknown(t *testing.T) {
+	if _, err := Select(selectionConfig(), Selection{Tools: []string{"rg"}}); err == nil {
+		t.Error("Expected error for unknown tool")
+	}
+	if _, err := Select(selectionConfig(), Selection{Tags: []string{"nope"}}); err == nil {
+		t.Error("Expected error for unknown tag")
+	}
+}
+
+func TestValidateConfig_Groups(t *testing.T) {
+	cfg := selectionConfig()
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+
+	cfg.Groups["broken"] = []string{"missing"}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Error("Expected error for group member not in tools")
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Shells are the named hook interpreters and their argv; {0} is the script
+// file. An inline sh hook runs as sh -c <script>.
+var Shells = map[string][]string{
+	"sh":     {"sh", "{0}"},
+	"bash":   {"bash", "--noprofile", "--norc", "-eo", "pipefail", "{0}"},
+	"zsh":    {"zsh", "--no-rcs", "-e", "{0}"},
+	"python": {"python3", "{0}"},
+}
+
+// Shell is the interpreter of a hook: a name of Shells, or the argv of a
+// command with a {0} placeholder. A list is used verbatim, so its elements
+// may contain spaces (["pwsh", "-Command", "& {0}"]); a string is a name or,
+// as a convenience, a command split on whitespace ("perl -w {0}").
+type Shell []string
+
+// ParseShell parses the string form of a shell
+func ParseShell(s string) Shell {
+	return Shell(strings.Fields(s))
+}
+
+// Argv returns the command of the shell, with named shells expanded
+func (s Shell) Argv() []string {
+	if len(s) == 1 {
+		if argv, ok := Shells[s[0]]; ok {
+			return slices.Clone(argv)
+		}
+	}
+	return slices.Clone(s)
+}
+
+// String formats the shell as a command line, quoting arguments with spaces
+func (s Shell) String() string {
+	args := make([]string, len(s))
+	for n, arg := range s {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args[n] = arg
+	}
+	return strings.Join(args, " ")
+}
+
+// ValidateShell checks a hook's shell: empty, a name of Shells, or a command
+// with a {0} placeholder
+func ValidateShell(shell Shell) error {
+	if len(shell) == 0 || slices.ContainsFunc(shell.Argv(), hasPlaceholder) {
+		return nil
+	}
+	return fmt.Errorf("unknown shell '%s' (expected sh, bash, zsh, python or a command with {0})", shell)
+}
+
+// hasPlaceholder reports whether arg takes the script file
+func hasPlaceholder(arg string) bool {
+	return strings.Contains(arg, "{0}")
+}
+
+// UnmarshalJSON accepts a string or a list of strings.
+// CUE decoding goes through this method as well.
+func (s *Shell) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = ParseShell(text)
+		return nil
+	}
+	var argv []string
+	if err := json.Unmarshal(data, &argv); err != nil {
+		return fmt.Errorf("shell must be a string or a list of strings, got %s", data)
+	}
+	*s = argv
+	return nil
+}
+
+// UnmarshalYAML accepts a string or a list of strings
+func (s *Shell) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*s = ParseShell(node.Value)
+		return nil
+	case yaml.SequenceNode:
+		var argv []string
+		if err := node.Decode(&argv); err != nil {
+			return err
+		}
+		*s = argv
+		return nil
+	}
+	return fmt.Errorf("line %d: shell must be a string or a list of strings", node.Line)
+}
+
+// UnmarshalTOML accepts a string or an array of strings
+func (s *Shell) UnmarshalTOML(data interface{}) error {
+	switch val := data.(type) {
+	case string:
+		*s = ParseShell(val)
+		return nil
+	case []interface{}:
+		argv := make([]string, len(val))
+		for n, arg := range val {
+			text, ok := arg.(string)
+			if !ok {
+				return fmt.Errorf("shell must be a list of strings, got %T", arg)
+			}
+			argv[n] = text
+		}
+		*s = argv
+		return nil
+	}
+	return fmt.Errorf("shell must be a string or a list of strings, got %T", data)
+}
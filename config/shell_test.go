@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestShell_Formats(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"JSON", "tools.json", `{"tools": {"jq": {"postinstall": [{"run": "true", "shell": ["pwsh", "-Command", "& {0}"]}, {"run": "true", "shell": "perl -w {0}"}]}}}`},
+		{"YAML", "tools.yaml", "tools:\n  jq:\n    postinstall:\n      - run: \"true\"\n        shell: [pwsh, -Command, \"& {0}\"]\n      - run: \"true\"\n        shell: perl -w {0}\n"},
+		{"TOML", "tools.toml", "[[tools.jq.postinstall]]\nrun = \"true\"\nshell = [\"pwsh\", \"-Command\", \"& {0}\"]\n\n[[tools.jq.postinstall]]\nrun = \"true\"\nshell = \"perl -w {0}\"\n"},
+		{"CUE", "tools.cue", "tools: jq: postinstall: [{run: \"true\", shell: [\"pwsh\", \"-Command\", \"& {0}\"]}, {run: \"true\", shell: \"perl -w {0}\"}]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+			if err := ValidateConfig(cfg); err != nil {
+				t.Fatalf("ValidateConfig failed: %v", err)
+			}
+
+			hooks := cfg.Tools["jq"].Postinstall
+			if got := hooks[0].Shell.Argv(); !slices.Equal(got, []string{"pwsh", "-Command", "& {0}"}) {
+				t.Errorf("Expected the list to be kept verbatim, got %q", got)
+			}
+			if got := hooks[1].Shell.Argv(); !slices.Equal(got, []string{"perl", "-w", "{0}"}) {
+				t.Errorf("Expected the string to be split, got %q", got)
+			}
+		})
+	}
+}
+
+func TestShell_Argv(t *testing.T) {
+	if got := (Shell{"bash"}).Argv(); !slices.Equal(got, Shells["bash"]) {
+		t.Errorf("Expected bash to expand to %q, got %q", Shells["bash"], got)
+	}
+	// Argv returns a copy, so placeholders can be replaced in place
+	argv := (Shell{"sh"}).Argv()
+	argv[1] = "/tmp/script"
+	if Shells["sh"][1] != "{0}" {
+		t.Error("Expected Argv not to share the named shell's argv")
+	}
+	if got := (Shell{"C:/Program Files/Git/bin/bash.exe", "{0}"}).String(); got != `"C:/Program Files/Git/bin/bash.exe" {0}` {
+		t.Errorf("String() = %s", got)
+	}
+}
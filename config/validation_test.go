@@ -118,3 +118,121 @@ func TestValidateYAMLWithSchema_EmptySchema(t *testing.T) {
 func writeFile(path string, data []byte) error {
 	return writeFile(path, data)
 }
+
+func TestValidateYAMLWithSchema_VersionConstraint(t *testing.T) {
+	valid := []byte(`
+tools:
+  go:
+    version: ">=1.20 <1.23"
+  python:
+    version: "~3.11"
+  node:
+    version: "^18 || ^20"
+  jq:
+    version: latest
+`)
+	if _, err := ValidateYAMLWithSchema(valid, SchemaCue); err != nil {
+		t.Errorf("Expected valid constraints to pass, got: %v", err)
+	}
+
+	invalid := []byte(`
+tools:
+  go:
+    version: ">=latest"
+`)
+	if _, err := ValidateYAMLWithSchema(invalid, SchemaCue); err == nil {
+		t.Error("Expected invalid constraint to fail schema validation")
+	}
+}
+
+func TestValidateYAMLWithSchema_ToolOptions(t *testing.T) {
+	valid := []byte(`
+tools:
+  ubi:BurntSushi/ripgrep:
+    options:
+      exe: rg
+      matching: musl
+      postinstall_timeout: 30
+`)
+	if _, err := ValidateYAMLWithSchema(valid, SchemaCue); err != nil {
+		t.Errorf("Expected valid options to pass, got: %v", err)
+	}
+
+	for _, option := range []string{`postinstall: "make install"`, `matching: "a,b"`, `matching: "a]b"`, `matching: "a=b"`, `"bin path": bin`} {
+		invalid := []byte("tools:\n  ubi:foo/bar:\n    options:\n      " + option + "\n")
+		if _, err := ValidateYAMLWithSchema(invalid, SchemaCue); err == nil {
+			t.Errorf("Expected option %s to fail schema validation", option)
+		}
+	}
+}
+
+func TestValidateYAMLWithSchema_Platforms(t *testing.T) {
+	valid := []byte(`
+tools:
+  node:
+    platforms: ["linux/amd64", "linux/arm64/musl", "darwin"]
+    postinstall:
+      - run: apk add libstdc++
+        if:
+          libc: [musl]
+          distro: [alpine]
+`)
+	if _, err := ValidateYAMLWithSchema(valid, SchemaCue); err != nil {
+		t.Errorf("Expected platform selectors to pass, got: %v", err)
+	}
+
+	invalid := []byte(`
+tools:
+  node:
+    if:
+      libc: [uclibc]
+`)
+	if _, err := ValidateYAMLWithSchema(invalid, SchemaCue); err == nil {
+		t.Error("Expected unknown libc to fail schema validation")
+	}
+}
+
+func TestValidateYAMLWithSchema_TagsAndGroups(t *testing.T) {
+	valid := []byte(`
+groups:
+  core: [jq]
+tools:
+  jq:
+    tags: [cli, core]
+`)
+	if _, err := ValidateYAMLWithSchema(valid, SchemaCue); err != nil {
+		t.Errorf("Expected tags and groups to pass, got: %v", err)
+	}
+
+	invalid := []byte(`
+tools:
+  jq:
+    tags: ["not a tag"]
+`)
+	if _, err := ValidateYAMLWithSchema(invalid, SchemaCue); err == nil {
+		t.Error("Expected invalid tag to fail schema validation")
+	}
+}
+
+func TestValidateYAMLWithSchema_HookOptions(t *testing.T) {
+	valid := []byte(`
+tools:
+  rust:
+    postinstall:
+      - run: cargo install ripgrep
+        timeout: 15m
+        retries: 2
+        retry_delay: 1m30s
+        continue_on_error: true
+`)
+	if _, err := ValidateYAMLWithSchema(valid, SchemaCue); err != nil {
+		t.Errorf("Expected hook options to validate, got %v", err)
+	}
+
+	for _, invalid := range []string{"timeout: 15 minutes", "retries: -1", "retry_delay: soon"} {
+		data := []byte("tools:\n  rust:\n    postinstall:\n      - run: make\n        " + invalid + "\n")
+		if _, err := ValidateYAMLWithSchema(data, SchemaCue); err == nil {
+			t.Errorf("Expected %q to fail validation", invalid)
+		}
+	}
+}
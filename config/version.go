@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is a tool version exactly as written in the config file.
+// Numeric literals such as `version: 1.20` are kept as their source text
+// instead of being converted to a float, so "1.20" never becomes "1.2".
+type Version string
+
+// String returns the version text
+func (v Version) String() string {
+	return string(v)
+}
+
+// UnmarshalJSON accepts a JSON string or number, keeping numbers as written.
+// CUE decoding goes through this method as well.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || string(data) == "null":
+		*v = ""
+	case data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = Version(s)
+	case data[0] == '-' || (data[0] >= '0' && data[0] <= '9'):
+		*v = Version(data)
+	default:
+		return fmt.Errorf("version must be a string or number, got %s", data)
+	}
+	return nil
+}
+
+// UnmarshalYAML accepts any scalar, keeping the literal text of numbers
+func (v *Version) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: version must be a scalar", node.Line)
+	}
+	if node.Tag == "!!null" {
+		*v = ""
+		return nil
+	}
+	*v = Version(node.Value)
+	return nil
+}
+
+// UnmarshalTOML accepts a TOML string, integer or float.
+// The TOML decoder only provides the parsed float, so ParseTOML restores
+// the source text afterwards (see FindNumericVersions).
+func (v *Version) UnmarshalTOML(data interface{}) error {
+	switch val := data.(type) {
+	case string:
+		*v = Version(val)
+	case int64:
+		*v = Version(strconv.FormatInt(val, 10))
+	case float64:
+		*v = Version(strconv.FormatFloat(val, 'f', -1, 64))
+	default:
+		return fmt.Errorf("version must be a string or number, got %T", data)
+	}
+	return nil
+}
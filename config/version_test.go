@@ -0,0 +1,188 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersion_NumericLiterals(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"JSON", "tools.json", `{"tools": {"go": {"version": 1.20}, "node": {"version": 20}, "jq": {"version": "1.7"}}}`},
+		{"YAML", "tools.yaml", "tools:\n  go:\n    version: 1.20\n  node:\n    version: 20\n  jq:\n    version: \"1.7\"\n"},
+		{"TOML", "tools.toml", "[tools.go]\nversion = 1.20\n\n[tools.node]\nversion = 20\n\n[tools.jq]\nversion = \"1.7\"\n"},
+		{"CUE", "tools.cue", "tools: {\n\tgo: version: 1.20\n\tnode: version: 20\n\tjq: version: \"1.7\"\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			if cfg.Tools["go"].Version != "1.20" {
+				t.Errorf("Expected go version 1.20, got %s", cfg.Tools["go"].Version)
+			}
+			if cfg.Tools["node"].Version != "20" {
+				t.Errorf("Expected node version 20, got %s", cfg.Tools["node"].Version)
+			}
+			if cfg.Tools["jq"].Version != "1.7" {
+				t.Errorf("Expected jq version 1.7, got %s", cfg.Tools["jq"].Version)
+			}
+
+			numeric := cfg.NumericVersions()
+			if len(numeric) != 2 {
+				t.Fatalf("Expected 2 numeric versions, got %d: %+v", len(numeric), numeric)
+			}
+			if numeric[0].Tool != "go" || numeric[0].Text != "1.20" {
+				t.Errorf("Expected go 1.20 first, got %+v", numeric[0])
+			}
+		})
+	}
+}
+
+func TestVersion_UnlocatedTOMLNumber(t *testing.T) {
+	// A multi-line array is beyond the line scanner; decoding it would
+	// silently turn 3.10 into 3.1
+	path := filepath.Join(t.TempDir(), "tools.toml")
+	data := "[tools.python]\nversions = [\n  {version = 3.10},\n]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := ParseFile(path)
+	if err == nil {
+		t.Fatal("Expected an error for a version the scanner cannot locate")
+	}
+	if !strings.Contains(err.Error(), "tools.python.versions.0.version") || !strings.Contains(err.Error(), "quote") {
+		t.Errorf("Expected an error naming the version, got: %v", err)
+	}
+}
+
+func TestQuoteNumericVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		data     string
+		expected string
+	}{
+		{
+			name:     "YAML",
+			format:   "yaml",
+			data:     "tools:\n  go:\n    version: 1.20 # pinned\n    postinstall:\n      - run: \"echo version: 1.2\"\n",
+			expected: "tools:\n  go:\n    version: \"1.20\" # pinned\n    postinstall:\n      - run: \"echo version: 1.2\"\n",
+		},
+		{
+			name:     "JSON",
+			format:   "json",
+			data:     "{\n  \"tools\": {\n    \"go\": {\"version\": 1.20}\n  }\n}\n",
+			expected: "{\n  \"tools\": {\n    \"go\": {\"version\": \"1.20\"}\n  }\n}\n",
+		},
+		{
+			name:     "TOML",
+			format:   "toml",
+			data:     "[tools.go]\nversion = 1.20\n\n[tools]\nnode = { version = 20 }\n",
+			expected: "[tools.go]\nversion = \"1.20\"\n\n[tools]\nnode = { version = \"20\" }\n",
+		},
+		{
+			name:     "CUE",
+			format:   "cue",
+			data:     "tools: go: version: 1.20\n",
+			expected: "tools: go: version: \"1.20\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, found, err := QuoteNumericVersions([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("QuoteNumericVersions failed: %v", err)
+			}
+			if len(found) == 0 {
+				t.Fatal("Expected numeric versions to be found")
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Unexpected output:\n%s\nexpected:\n%s", out, tt.expected)
+			}
+
+			// Formatting is idempotent
+			again, found, err := QuoteNumericVersions(out, tt.format)
+			if err != nil {
+				t.Fatalf("QuoteNumericVersions failed: %v", err)
+			}
+			if len(found) != 0 || string(again) != string(out) {
+				t.Errorf("Expected no further changes, got %d", len(found))
+			}
+		})
+	}
+}
+
+func TestFormatFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.yaml")
+	if err := os.WriteFile(path, []byte("tools:\n  python:\n    version: 3.10\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	rewritten, err := FormatFile(path)
+	if err != nil {
+		t.Fatalf("FormatFile failed: %v", err)
+	}
+	if len(rewritten) != 1 {
+		t.Fatalf("Expected 1 rewritten version, got %d", len(rewritten))
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `version: "3.10"`) {
+		t.Errorf("Expected quoted version, got:\n%s", data)
+	}
+}
+
+func TestVersion_VersionsList(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"JSON", "tools.json", `{"tools": {"python": {"versions": [{"version": 3.10}, {"version": "3.12", "default": true}]}}}`},
+		{"YAML", "tools.yaml", "tools:\n  python:\n    versions:\n      - version: 3.10\n      - version: \"3.12\"\n        default: true\n"},
+		{"TOML", "tools.toml", "[[tools.python.versions]]\nversion = 3.10\n\n[[tools.python.versions]]\nversion = \"3.12\"\ndefault = true\n"},
+		{"TOML inline", "tools.toml", "[tools.python]\nversions = [{ version = 3.10 }, { version = \"3.12\", default = true }]\n"},
+		{"CUE", "tools.cue", "tools: python: versions: [{version: 3.10}, {version: \"3.12\", default: true}]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			versions := cfg.Tools["python"].Versions
+			if len(versions) != 2 {
+				t.Fatalf("Expected 2 versions, got %d", len(versions))
+			}
+			if versions[0].Version != "3.10" {
+				t.Errorf("Expected first version 3.10, got %s", versions[0].Version)
+			}
+			if !versions[1].Default || versions[1].Version != "3.12" {
+				t.Errorf("Expected 3.12 as default, got %+v", versions[1])
+			}
+		})
+	}
+}
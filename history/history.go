@@ -0,0 +1,256 @@
+// Package history keeps a journal of the mise-seq runs that change a machine
+// (install, upgrade, rollback), next to the hook state:
+//
+//	<state-dir>/.history/<run-id>.json
+//
+// Each entry records the config, every tool's active version before and
+// after the run, the action taken and the hook results, so a run can be
+// inspected and rolled back later.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/mise"
+	"github.com/mise-seq/config-loader/report"
+)
+
+// SchemaVersion is the version of the entry format
+const SchemaVersion = 1
+
+// dirName is the journal directory in the state directory. The dot keeps it
+// apart from the per-tool hook state directories.
+const dirName = ".history"
+
+// Entry is the journal entry of one run
+type Entry struct {
+	SchemaVersion int       `json:"schema_version" yaml:"schema_version"`
+	ID            string    `json:"id" yaml:"id"`
+	Command       string    `json:"command" yaml:"command"`
+	Config        string    `json:"config,omitempty" yaml:"config,omitempty"`
+	ConfigHash    string    `json:"config_hash,omitempty" yaml:"config_hash,omitempty"`
+	RollbackOf    string    `json:"rollback_of,omitempty" yaml:"rollback_of,omitempty"`
+	StartedAt     time.Time `json:"started_at" yaml:"started_at"`
+	FinishedAt    time.Time `json:"finished_at" yaml:"finished_at"`
+	Success       bool      `json:"success" yaml:"success"`
+	ExitCode      int       `json:"exit_code" yaml:"exit_code"`
+	Tools         []Tool    `json:"tools" yaml:"tools"`
+}
+
+// Tool is what a run did to one tool. Before and After are the active
+// versions; empty if the tool was not installed.
+type Tool struct {
+	Name   string `json:"name" yaml:"name"`
+	Before string `json:"before,omitempty" yaml:"before,omitempty"`
+	After  string `json:"after,omitempty" yaml:"after,omitempty"`
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+	Hooks  []Hook `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+}
+
+// Hook is the result of a hook run (or skipped) during the run
+type Hook struct {
+	Type        string `json:"type" yaml:"type"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Script      string `json:"script" yaml:"script"`
+	Status      string `json:"status,omitempty" yaml:"status,omitempty"`
+	Skipped     bool   `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	SkipReason  string `json:"skip_reason,omitempty" yaml:"skip_reason,omitempty"`
+	ExitCode    int    `json:"exit_code" yaml:"exit_code"`
+	DurationMs  int64  `json:"duration_ms" yaml:"duration_ms"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// New builds the entry of a finished run from its report and the mise
+// inventory before and after it. Excluded tools are left out.
+func New(rep *report.Report, configHash string, before, after mise.Inventory) *Entry {
+	e := &Entry{
+		SchemaVersion: SchemaVersion,
+		ID:            rep.RunID,
+		Command:       rep.Command,
+		Config:        rep.Config,
+		ConfigHash:    configHash,
+		StartedAt:     rep.StartedAt,
+		FinishedAt:    rep.FinishedAt,
+		Success:       rep.Success,
+		ExitCode:      rep.ExitCode,
+		Tools:         []Tool{},
+	}
+	for _, t := range rep.Tools {
+		if t.Status == report.StatusExcluded {
+			continue
+		}
+		tool := Tool{
+			Name:   t.Name,
+			Before: before.Active(t.Name),
+			After:  after.Active(t.Name),
+			Action: t.Action,
+			Status: t.Status,
+			Error:  t.Error,
+		}
+		for _, h := range t.Hooks {
+			tool.Hooks = append(tool.Hooks, Hook{
+				Type:        h.Type,
+				Description: h.Description,
+				Script:      h.Script,
+				Status:      h.Status,
+				Skipped:     h.Skipped,
+				SkipReason:  h.SkipReason,
+				ExitCode:    h.ExitCode,
+				DurationMs:  h.DurationMs,
+				Error:       h.Error,
+			})
+		}
+		e.Tools = append(e.Tools, tool)
+	}
+	return e
+}
+
+// Changed returns the tools whose active version the run changed
+func (e *Entry) Changed() []Tool {
+	var changed []Tool
+	for _, t := range e.Tools {
+		if t.Before != t.After {
+			changed = append(changed, t)
+		}
+	}
+	return changed
+}
+
+// Changes summarizes the version changes, e.g. "jq 1.6 -> 1.7, rg new 14.1"
+func (e *Entry) Changes() string {
+	var parts []string
+	for _, t := range e.Changed() {
+		switch {
+		case t.Before == "":
+			parts = append(parts, fmt.Sprintf("%s new %s", t.Name, t.After))
+		case t.After == "":
+			parts = append(parts, fmt.Sprintf("%s %s removed", t.Name, t.Before))
+		default:
+			parts = append(parts, fmt.Sprintf("%s %s -> %s", t.Name, t.Before, t.After))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Dir returns the journal directory in stateDir
+func Dir(stateDir string) string {
+	return filepath.Join(stateDir, dirName)
+}
+
+// Save writes the entry to the journal in stateDir
+func Save(stateDir string, e *Entry) error {
+	if e.ID == "" {
+		return fmt.Errorf("history entry has no run id")
+	}
+	dir := Dir(stateDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, e.ID+".json"), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return nil
+}
+
+// Load reads the entry of run id from the journal in stateDir
+func Load(stateDir, id string) (*Entry, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid run id '%s'", id)
+	}
+	data, err := os.ReadFile(filepath.Join(Dir(stateDir), id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no run '%s' in history (see mise-seq history)", id)
+		}
+		return nil, fmt.Errorf("failed to read history entry: %w", err)
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse history entry %s: %w", id, err)
+	}
+	if e.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("history entry %s has schema version %d, expected %d", id, e.SchemaVersion, SchemaVersion)
+	}
+	return &e, nil
+}
+
+// List returns the entries of the journal in stateDir, oldest first
+func List(stateDir string) ([]*Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(Dir(stateDir), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	entries := make([]*Entry, 0, len(paths))
+	for _, path := range paths {
+		e, err := Load(stateDir, strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	// Run ids sort by second; the start time orders runs within one
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StartedAt.Before(entries[j].StartedAt) })
+	return entries, nil
+}
+
+// Rollback re-activates the versions tools had before run e, with mise use
+// -g, which reinstalls them if needed. Tools the run installed for the first
+// time are left in place. Each tool is recorded in rep; it returns an error
+// if any tool could not be rolled back.
+func Rollback(ctx context.Context, client *mise.Client, w io.Writer, e *Entry, rep *report.Report) error {
+	changed := e.Changed()
+	if len(changed) == 0 {
+		fmt.Fprintf(w, "Run %s changed no tool versions; nothing to roll back\n", e.ID)
+		return nil
+	}
+
+	var failed []string
+	for _, t := range changed {
+		res := rep.Tool(t.Name)
+		res.Action = "rollback"
+		res.Version = t.Before
+		if t.Before == "" {
+			res.Status = string(mise.ToolSkipped)
+			res.Reason = "not installed before the run"
+			fmt.Fprintf(w, "  %s: not installed before %s; leaving %s\n", t.Name, e.ID, t.After)
+			continue
+		}
+
+		fmt.Fprintf(w, "  %s: %s -> %s\n", t.Name, t.After, t.Before)
+		start := time.Now()
+		err := client.SetGlobal(ctx, t.Name+"@"+t.Before)
+		res.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			res.Status = string(mise.ToolFailed)
+			res.Error = err.Error()
+			failed = append(failed, t.Name)
+			fmt.Fprintf(w, "  %s: %v\n", t.Name, err)
+			continue
+		}
+		res.Status = string(mise.ToolSucceeded)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("rollback failed for: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
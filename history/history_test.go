@@ -0,0 +1,152 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/mise"
+	"github.com/mise-seq/config-loader/report"
+)
+
+// installReport is the report of an install that upgraded jq, installed rg
+// and left out mas
+func installReport() *report.Report {
+	rep := report.New("install")
+	rep.RunID = "20261018T120000Z-abcdef"
+	rep.Config = "tools.yaml"
+	rep.OnEvent(mise.Event{
+		Type:       mise.EventHookFinished,
+		Tool:       "jq",
+		HookType:   hooks.HookTypePostinstall,
+		Script:     "jq --version",
+		HookResult: &hooks.HookResult{ExitCode: 1, Duration: 20 * time.Millisecond},
+		Err:        errors.New("hook failed with exit code 1"),
+	})
+	rep.ApplySummary(&mise.Summary{Results: []*mise.ToolResult{
+		{Tool: "jq", Action: "install", Status: mise.ToolFailed},
+		{Tool: "rg", Action: "install", Status: mise.ToolSucceeded},
+		{Tool: "yq", Action: "install", Status: mise.ToolSucceeded},
+	}})
+	rep.AddExcluded([]config.Exclusion{{Tool: "mas", Reason: "requires os darwin"}})
+	rep.Finish(errors.New("installation failed: jq"), 1)
+	return rep
+}
+
+func TestNew(t *testing.T) {
+	before := mise.Inventory{
+		"jq": {{Version: "1.6", Installed: true, Active: true}},
+		"yq": {{Version: "4.40", Installed: true, Active: true}},
+	}
+	after := mise.Inventory{
+		"jq": {{Version: "1.6", Installed: true}, {Version: "1.7", Installed: true, Active: true}},
+		"rg": {{Version: "14.1", Installed: true, Active: true}},
+		"yq": {{Version: "4.40", Installed: true, Active: true}},
+	}
+	e := New(installReport(), "abc123", before, after)
+
+	if e.ID != "20261018T120000Z-abcdef" || e.Command != "install" || e.ConfigHash != "abc123" || e.Success || e.ExitCode != 1 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if len(e.Tools) != 3 {
+		t.Fatalf("expected 3 tools (excluded left out), got %+v", e.Tools)
+	}
+	jq := e.Tools[0]
+	if jq.Name != "jq" || jq.Before != "1.6" || jq.After != "1.7" || jq.Status != "failed" {
+		t.Errorf("jq = %+v", jq)
+	}
+	if len(jq.Hooks) != 1 || jq.Hooks[0].Error == "" || jq.Hooks[0].DurationMs != 20 {
+		t.Errorf("jq hooks = %+v", jq.Hooks)
+	}
+	if got, want := e.Changes(), "jq 1.6 -> 1.7, rg new 14.1"; got != want {
+		t.Errorf("Changes() = %q, expected %q", got, want)
+	}
+}
+
+func TestSaveLoadList(t *testing.T) {
+	stateDir := t.TempDir()
+
+	if entries, err := List(stateDir); err != nil || len(entries) != 0 {
+		t.Fatalf("List() of empty state = %v, %v", entries, err)
+	}
+
+	now := time.Now().UTC()
+	second := &Entry{SchemaVersion: SchemaVersion, ID: "20261018T120000Z-000000", Command: "upgrade", StartedAt: now}
+	first := &Entry{SchemaVersion: SchemaVersion, ID: "20261018T120000Z-ffffff", Command: "install", StartedAt: now.Add(-time.Second)}
+	for _, e := range []*Entry{second, first} {
+		if err := Save(stateDir, e); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+	}
+
+	got, err := Load(stateDir, first.ID)
+	if err != nil || got.Command != "install" {
+		t.Fatalf("Load() = %+v, %v", got, err)
+	}
+
+	entries, err := List(stateDir)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[1].ID != second.ID {
+		t.Errorf("List() is not in start order: %+v", entries)
+	}
+
+	if _, err := Load(stateDir, "missing"); err == nil || !strings.Contains(err.Error(), "no run 'missing'") {
+		t.Errorf("Load(missing) error = %v", err)
+	}
+	if _, err := Load(stateDir, "../etc/passwd"); err == nil {
+		t.Error("Load() accepted a path as run id")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	// mise stub logging its arguments, failing for yq
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\n" +
+		"echo \"$*\" >> " + logFile + "\n" +
+		"case \"$*\" in\n" +
+		"  \"use -g yq@\"*) echo 'no such version' >&2; exit 1 ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(filepath.Join(dir, "mise"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write mise stub: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	e := &Entry{ID: "run-1", Tools: []Tool{
+		{Name: "jq", Before: "1.6", After: "1.7"},
+		{Name: "rg", After: "14.1"},
+		{Name: "yq", Before: "4.40", After: "4.44"},
+		{Name: "node", Before: "20", After: "20"},
+	}}
+	rep := report.New("rollback")
+	var out bytes.Buffer
+	err := Rollback(context.Background(), mise.NewClient(), &out, e, rep)
+	if err == nil || !strings.Contains(err.Error(), "rollback failed for: yq") {
+		t.Fatalf("Rollback() error = %v, expected yq to fail", err)
+	}
+
+	calls, _ := os.ReadFile(logFile)
+	if got, want := strings.TrimSpace(string(calls)), "use -g jq@1.6\nuse -g yq@4.40"; got != want {
+		t.Errorf("mise calls = %q, expected %q", got, want)
+	}
+
+	status := map[string]string{}
+	for _, tool := range rep.Tools {
+		status[tool.Name] = tool.Status
+	}
+	if status["jq"] != "succeeded" || status["rg"] != "skipped" || status["yq"] != "failed" || status["node"] != "" {
+		t.Errorf("rollback statuses = %v", status)
+	}
+	if !strings.Contains(out.String(), "rg: not installed before run-1") {
+		t.Errorf("output does not mention the new tool:\n%s", out.String())
+	}
+}
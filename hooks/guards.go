@@ -0,0 +1,108 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mise-seq/config-loader/proc"
+)
+
+// Guards are conditions checked before a hook runs, like Ansible's creates,
+// removes, unless and when. A hook is skipped if any guard says so.
+type Guards struct {
+	Creates string // skip if this path exists
+	Removes string // skip if this path does not exist
+	Unless  string // skip if this command succeeds
+	OnlyIf  string // skip if this command fails
+}
+
+// ChecksResult reports whether the guards check what the hook does (creates,
+// removes or unless). Such a hook runs whenever its guards allow it, even if
+// its state is unchanged, so deleting what it created re-runs it.
+func (g Guards) ChecksResult() bool {
+	return g.Creates != "" || g.Removes != "" || g.Unless != ""
+}
+
+// CheckGuards evaluates the guards of opts and returns why the hook should be
+// skipped, or "" if it may run. Guard commands run with sh -c in the hook's
+// working directory and env; they should not change anything, as they also
+// run for dry runs and plans.
+func CheckGuards(ctx context.Context, opts Options) (string, error) {
+	g := opts.Guards
+	if g.Creates != "" {
+		exists, err := pathExists(g.Creates)
+		if err != nil {
+			return "", fmt.Errorf("creates: %w", err)
+		}
+		if exists {
+			return fmt.Sprintf("creates: %s exists", g.Creates), nil
+		}
+	}
+	if g.Removes != "" {
+		exists, err := pathExists(g.Removes)
+		if err != nil {
+			return "", fmt.Errorf("removes: %w", err)
+		}
+		if !exists {
+			return fmt.Sprintf("removes: %s does not exist", g.Removes), nil
+		}
+	}
+	if g.OnlyIf != "" {
+		ok, err := guardCommand(ctx, g.OnlyIf, opts)
+		if err != nil {
+			return "", fmt.Errorf("only_if: %w", err)
+		}
+		if !ok {
+			return fmt.Sprintf("only_if: %s failed", firstLine(g.OnlyIf)), nil
+		}
+	}
+	if g.Unless != "" {
+		ok, err := guardCommand(ctx, g.Unless, opts)
+		if err != nil {
+			return "", fmt.Errorf("unless: %w", err)
+		}
+		if ok {
+			return fmt.Sprintf("unless: %s succeeded", firstLine(g.Unless)), nil
+		}
+	}
+	return "", nil
+}
+
+// pathExists reports whether path exists
+func pathExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// guardCommand runs a guard command and reports whether it exited 0. Only a
+// command that could not be run at all is an error.
+func guardCommand(ctx context.Context, command string, opts Options) (bool, error) {
+	cmd := proc.Command(ctx, "sh", "-c", command)
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Environ()...)
+	}
+	err := proc.Run(cmd)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// firstLine returns the first line of s, to name a guard command in a reason
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/proc"
 )
 
 // HookType represents the type of hook
@@ -35,25 +40,94 @@ func (r *Runner) IsVerbose() bool {
 	return r.verbose
 }
 
+// HookStatus is the outcome of a hook
+type HookStatus string
+
+const (
+	HookSucceeded HookStatus = "succeeded"
+	HookFailed    HookStatus = "failed"    // exited non-zero or could not start
+	HookTimedOut  HookStatus = "timed_out" // killed after its timeout
+	HookSkipped   HookStatus = "skipped"   // state unchanged or a guard said so
+)
+
 // HookResult represents the result of a hook execution
 type HookResult struct {
 	ToolName   string
 	HookType   HookType
 	Script     string
+	Command    []string // the command that ran the script
+	Status     HookStatus
 	ExitCode   int
 	Stdout     string
 	Stderr     string
 	Error      error
-	Duration   time.Duration
+	Duration   time.Duration // of all attempts
+	Attempts   int
 	Skipped    bool
+	SkipReason string // why the hook was skipped: its state or a guard
 	SHA256Hash string
+
+	// ContinuedOnError is set when the hook failed but continue_on_error
+	// let the run go on; Error holds the failure
+	ContinuedOnError bool
+}
+
+// DefaultTimeout is how long a hook without its own timeout may run
+const DefaultTimeout = 5 * time.Minute
+
+// SkipStateUnchanged is the skip reason of a hook that already ran with the
+// same script, working directory and env
+const SkipStateUnchanged = "state unchanged"
+
+// Options control how one hook runs. The zero value runs it once, with the
+// runner's timeout.
+type Options struct {
+	Timeout         time.Duration // overrides the runner's timeout
+	Retries         int           // extra attempts after a failure or timeout
+	RetryDelay      time.Duration // wait between attempts
+	ContinueOnError bool          // a failure does not fail the tool
+
+	// Shell is the interpreter (see config.Shells); none runs sh -c <script>
+	Shell config.Shell
+	// File is the script file to run; the script is written to a temporary
+	// file instead if the shell needs one
+	File string
+
+	// Env is set on top of the inherited environment, and Dir is the
+	// working directory ("" to inherit). Both are part of the hook state.
+	Env map[string]string
+	Dir string
+
+	// Guards decide whether the hook runs, before its state
+	Guards Guards
+}
+
+// HookOptions returns the options of a configured hook. env are the outer
+// env blocks (defaults, then tool); the hook's own env is merged over them.
+func HookOptions(h config.Hook, env ...map[string]string) Options {
+	return Options{
+		Env:             config.MergeEnv(append(env, h.Env)...),
+		Dir:             h.CwdPath(),
+		Timeout:         h.TimeoutDuration(),
+		Retries:         h.Retries,
+		RetryDelay:      h.RetryDelayDuration(),
+		ContinueOnError: h.ContinueOnError,
+		Shell:           h.Shell,
+		File:            h.FilePath(),
+		Guards: Guards{
+			Creates: h.CreatesPath(),
+			Removes: h.RemovesPath(),
+			Unless:  h.Unless,
+			OnlyIf:  h.OnlyIf,
+		},
+	}
 }
 
 // NewRunner creates a new hook runner
 func NewRunner(dryRun bool) *Runner {
 	return &Runner{
 		dryRun:   dryRun,
-		timeout:  5 * time.Minute,
+		timeout:  DefaultTimeout,
 		verbose:  false,
 		stateMgr: NewStateManager(),
 	}
@@ -73,17 +147,32 @@ func NewRunnerWithOptions(dryRun bool, stateDir string, forceHooks, runPostinsta
 // RunDefaultsHook runs a default hook with state management
 // The key is used for state tracking (e.g., "defaults.preinstall")
 func (r *Runner) RunDefaultsHook(ctx context.Context, hookType HookType, scripts []string) ([]*HookResult, error) {
+	hookList := make([]config.Hook, len(scripts))
+	for n, script := range scripts {
+		hookList[n] = config.Hook{Run: script}
+	}
+	return r.RunDefaults(ctx, hookType, hookList, nil)
+}
+
+// RunDefaults runs default hooks with state management, applying the
+// options of each hook and the defaults env
+func (r *Runner) RunDefaults(ctx context.Context, hookType HookType, hookList []config.Hook, env map[string]string) ([]*HookResult, error) {
 	toolName := "defaults"
-	results := make([]*HookResult, 0, len(scripts))
+	results := make([]*HookResult, 0, len(hookList))
 	var lastError error
 
-	for _, script := range scripts {
-		script = strings.TrimSpace(script)
+	for _, hook := range hookList {
+		script, err := hook.Source()
+		if err != nil {
+			results = append(results, &HookResult{ToolName: toolName, HookType: hookType, Status: HookFailed, Error: err})
+			lastError = err
+			continue
+		}
 		if script == "" {
 			continue
 		}
 
-		result, err := r.Run(ctx, toolName, hookType, script)
+		result, err := r.RunWithOptions(ctx, toolName, hookType, script, HookOptions(hook, env))
 		results = append(results, result)
 
 		if err != nil {
@@ -94,29 +183,64 @@ func (r *Runner) RunDefaultsHook(ctx context.Context, hookType HookType, scripts
 	return results, lastError
 }
 
-// SetTimeout sets the hook execution timeout
+// SetTimeout sets the execution timeout of hooks without their own
+// (DefaultTimeout unless set); 0 means no timeout
 func (r *Runner) SetTimeout(timeout time.Duration) {
 	r.timeout = timeout
 }
 
 // Run executes a hook script with state management
 func (r *Runner) Run(ctx context.Context, toolName string, hookType HookType, script string) (*HookResult, error) {
+	return r.RunWithOptions(ctx, toolName, hookType, script, Options{})
+}
+
+// RunWithOptions executes a hook script with state management, unless one of
+// its guards skips it (see CheckGuards). A failed or timed out attempt is
+// retried opts.Retries times. With opts.ContinueOnError a failure is returned
+// in the result only, with a nil error.
+func (r *Runner) RunWithOptions(ctx context.Context, toolName string, hookType HookType, script string, opts Options) (*HookResult, error) {
 	result := &HookResult{
 		ToolName: toolName,
 		HookType: hookType,
 		Script:   script,
 	}
 
-	// Check if hook should run based on state
-	shouldRun, existingHash, err := r.stateMgr.ShouldRunHook(toolName, string(hookType), script)
+	// Guards look at the machine, so they are checked before the state
+	reason, err := CheckGuards(ctx, opts)
+	if err != nil {
+		result.Status = HookFailed
+		result.ExitCode = -1
+		result.Error = fmt.Errorf("failed to check hook guards: %w", err)
+		if opts.ContinueOnError {
+			result.ContinuedOnError = true
+			return result, nil
+		}
+		return result, result.Error
+	}
+	if reason != "" {
+		config.DebugContext(ctx, "Skipping %s hook for %s: %s", hookType, toolName, reason)
+		result.Skipped = true
+		result.Status = HookSkipped
+		result.SkipReason = reason
+		return result, nil
+	}
+
+	// Check if hook should run based on state. Guards checking the hook's
+	// result (creates, removes, unless) take its place: they passed, so the
+	// work is missing even if the script is unchanged.
+	stateContent := opts.StateContent(script)
+	shouldRun, existingHash, err := r.stateMgr.ShouldRunHook(toolName, string(hookType), stateContent)
 	if err != nil {
 		return result, fmt.Errorf("failed to check hook state: %w", err)
 	}
 
 	result.SHA256Hash = existingHash
 
-	if !shouldRun {
+	if !shouldRun && !opts.Guards.ChecksResult() {
+		config.DebugContext(ctx, "Skipping %s hook for %s: state unchanged", hookType, toolName)
 		result.Skipped = true
+		result.Status = HookSkipped
+		result.SkipReason = SkipStateUnchanged
 		if r.verbose {
 			result.Stdout = fmt.Sprintf("[skip] Hook unchanged (SHA256: %s)", existingHash[:8])
 		}
@@ -124,6 +248,7 @@ func (r *Runner) Run(ctx context.Context, toolName string, hookType HookType, sc
 	}
 
 	if r.dryRun {
+		config.DebugContext(ctx, "Dry run: not running %s hook for %s", hookType, toolName)
 		result.Stdout = "[dry-run] Would execute: " + script
 		return result, nil
 	}
@@ -132,23 +257,97 @@ func (r *Runner) Run(ctx context.Context, toolName string, hookType HookType, sc
 		return result, nil
 	}
 
-	// Use sh -c to execute the script
-	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = r.timeout
+	}
+	for {
+		result.Attempts++
+		config.DebugContext(ctx, "Running %s hook for %s (attempt %d)", hookType, toolName, result.Attempts)
+		r.runOnce(ctx, result, opts, timeout)
+		if result.Error == nil || ctx.Err() != nil || result.Attempts > opts.Retries {
+			break
+		}
+		config.WarnContext(ctx, "%s hook for %s failed (attempt %d of %d), retrying in %s: %v",
+			hookType, toolName, result.Attempts, opts.Retries+1, opts.RetryDelay, result.Error)
+		if !sleep(ctx, opts.RetryDelay) {
+			break
+		}
+	}
+
+	// An interrupted hook may have stopped partway, even if it exited 0; it
+	// is never recorded as run
+	if ctx.Err() != nil {
+		result.Status = HookFailed
+		result.Error = fmt.Errorf("hook stopped: %w", context.Cause(ctx))
+		return result, result.Error
+	}
+
+	// Save state on success (or always save for tracking)
+	if result.Error == nil || r.stateMgr.ForceHooks {
+		if saveErr := r.stateMgr.SaveHookState(toolName, string(hookType), stateContent); saveErr != nil {
+			// Log but don't fail
+			config.WarnContext(ctx, "Failed to save %s hook state for %s: %v", hookType, toolName, saveErr)
+			if r.verbose {
+				result.Stdout += fmt.Sprintf("\n[warn] Failed to save state: %v", saveErr)
+			}
+		}
+	}
+
+	if result.Error != nil && opts.ContinueOnError {
+		result.ContinuedOnError = true
+		return result, nil
+	}
+	return result, result.Error
+}
+
+// runOnce runs one attempt of result's script, killing it after timeout
+// (0 for none), and records its output, exit code and status
+func (r *Runner) runOnce(ctx context.Context, result *HookResult, opts Options, timeout time.Duration) {
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	argv, cleanup, err := command(result.Script, opts)
+	if err != nil {
+		result.Status = HookFailed
+		result.ExitCode = -1
+		result.Error = fmt.Errorf("hook execution failed: %w", err)
+		return
+	}
+	defer cleanup()
+	result.Command = argv
+
+	cmd := proc.Command(runCtx, argv[0], argv[1:]...)
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Environ()...)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	startTime := time.Now()
-
-	err = cmd.Run()
-	duration := time.Since(startTime)
+	err = proc.Run(cmd)
+	result.Duration += time.Since(startTime)
 
 	result.Stdout = stdout.String()
 	result.Stderr = stderr.String()
-	result.Duration = duration
-
-	if err != nil {
+	result.ExitCode = 0
+	result.Error = nil
+	result.Status = HookSucceeded
+
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		result.Status = HookTimedOut
+		result.ExitCode = -1
+		result.Error = fmt.Errorf("hook timed out after %s: %w", timeout, context.DeadlineExceeded)
+	case err != nil:
+		result.Status = HookFailed
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
 			result.Error = fmt.Errorf("hook failed with exit code %d: %s", exitErr.ExitCode(), stderr.String())
@@ -156,18 +355,92 @@ func (r *Runner) Run(ctx context.Context, toolName string, hookType HookType, sc
 			result.Error = fmt.Errorf("hook execution failed: %w", err)
 		}
 	}
+}
 
-	// Save state on success (or always save for tracking)
-	if result.Error == nil || r.stateMgr.ForceHooks {
-		if saveErr := r.stateMgr.SaveHookState(toolName, string(hookType), script); saveErr != nil {
-			// Log but don't fail
-			if r.verbose {
-				result.Stdout += fmt.Sprintf("\n[warn] Failed to save state: %v", saveErr)
-			}
+// Environ returns the env of o as sorted KEY=value pairs
+func (o Options) Environ() []string {
+	environ := make([]string, 0, len(o.Env))
+	for name, value := range o.Env {
+		environ = append(environ, name+"="+value)
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+// StateContent returns what the state of a hook running script with o is
+// keyed by: the script, plus its working directory and env if set, so that
+// changing them re-runs the hook
+func (o Options) StateContent(script string) string {
+	if o.Dir == "" && len(o.Env) == 0 {
+		return script
+	}
+	var b strings.Builder
+	b.WriteString(script)
+	if o.Dir != "" {
+		b.WriteString("\n# cwd: " + o.Dir)
+	}
+	for _, kv := range o.Environ() {
+		b.WriteString("\n# env: " + kv)
+	}
+	return b.String()
+}
+
+// command returns the argv running script with opts.Shell, and a function
+// removing the temporary script file it may have written. An inline script
+// without a shell runs as sh -c <script>.
+func command(script string, opts Options) ([]string, func(), error) {
+	cleanup := func() {}
+	if len(opts.Shell) == 0 && opts.File == "" {
+		return []string{"sh", "-c", script}, cleanup, nil
+	}
+
+	shell := opts.Shell
+	if len(shell) == 0 {
+		shell = config.Shell{"sh"}
+	}
+	if err := config.ValidateShell(shell); err != nil {
+		return nil, nil, err
+	}
+
+	path := opts.File
+	if path == "" {
+		f, err := os.CreateTemp("", "mise-seq-hook-*")
+		if err != nil {
+			return nil, nil, err
+		}
+		path = f.Name()
+		cleanup = func() { os.Remove(path) }
+		if _, err := f.WriteString(script); err != nil {
+			f.Close()
+			cleanup()
+			return nil, nil, err
+		}
+		if err := f.Close(); err != nil {
+			cleanup()
+			return nil, nil, err
 		}
 	}
 
-	return result, result.Error
+	argv := shell.Argv()
+	for n, arg := range argv {
+		argv[n] = strings.ReplaceAll(arg, "{0}", path)
+	}
+	return argv, cleanup, nil
+}
+
+// sleep waits for d, or until ctx is done; it reports whether d passed
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // RunHooks executes multiple hooks for a tool and returns all results
@@ -1,9 +1,15 @@
 package hooks
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
 )
 
 func TestStateManager_NewStateManager(t *testing.T) {
@@ -166,3 +172,251 @@ func TestRunner_EmptyScript(t *testing.T) {
 		t.Errorf("Expected exit code 0 for empty script, got %d", result.ExitCode)
 	}
 }
+
+func TestRunner_InterruptedHookKeepsNoState(t *testing.T) {
+	stateDir := t.TempDir()
+	runner := NewRunnerWithOptions(false, stateDir, true, false)
+
+	// The hook exits 0 on SIGTERM, as if it finished
+	script := "trap 'exit 0' TERM; sleep 5 & wait"
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	if _, err := runner.Run(ctx, "testtool", HookTypePostinstall, script); err == nil || !strings.Contains(err.Error(), "hook stopped") {
+		t.Fatalf("Expected an interrupted hook error, got %v", err)
+	}
+
+	runner.stateMgr.ForceHooks = false
+	shouldRun, _, err := runner.stateMgr.ShouldRunHook("testtool", string(HookTypePostinstall), script)
+	if err != nil {
+		t.Fatalf("ShouldRunHook failed: %v", err)
+	}
+	if !shouldRun {
+		t.Error("Expected no state to be saved for an interrupted hook")
+	}
+}
+
+func TestRunner_Timeout(t *testing.T) {
+	runner := NewRunnerWithOptions(false, t.TempDir(), false, false)
+
+	result, err := runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, "sleep 5", Options{Timeout: 100 * time.Millisecond})
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if result.Status != HookTimedOut || result.ExitCode != -1 {
+		t.Errorf("Expected status %s with exit code -1, got %s %d", HookTimedOut, result.Status, result.ExitCode)
+	}
+
+	// A non-zero exit is a different state
+	result, _ = runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, "exit 2", Options{Timeout: time.Minute})
+	if result.Status != HookFailed || result.ExitCode != 2 {
+		t.Errorf("Expected status %s with exit code 2, got %s %d", HookFailed, result.Status, result.ExitCode)
+	}
+}
+
+func TestRunner_DefaultTimeout(t *testing.T) {
+	if runner := NewRunner(false); runner.timeout != 5*time.Minute || DefaultTimeout != 5*time.Minute {
+		t.Errorf("Expected hooks to be bounded at 5m by default, got %v", runner.timeout)
+	}
+
+	// A hook's own timeout overrides the runner's
+	runner := NewRunnerWithOptions(false, t.TempDir(), false, false)
+	runner.SetTimeout(100 * time.Millisecond)
+	result, err := runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, "sleep 0.3", Options{Timeout: time.Minute})
+	if err != nil || result.Status != HookSucceeded {
+		t.Errorf("Expected the hook timeout to override the runner's, got %v %v", result.Status, err)
+	}
+	result, _ = runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, "sleep 5", Options{})
+	if result.Status != HookTimedOut {
+		t.Errorf("Expected the runner timeout to apply without a hook timeout, got %s", result.Status)
+	}
+}
+
+func TestRunner_Retries(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewRunnerWithOptions(false, dir, false, false)
+
+	// Fails until the third attempt
+	counter := filepath.Join(dir, "attempts")
+	script := "echo x >> " + counter + "; test $(wc -l < " + counter + ") -ge 3"
+
+	result, err := runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, script, Options{Retries: 1})
+	if err == nil || result.Attempts != 2 {
+		t.Fatalf("Expected failure after 2 attempts, got %d attempts, err %v", result.Attempts, err)
+	}
+
+	result, err = runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, script, Options{Retries: 2, RetryDelay: 10 * time.Millisecond})
+	if err != nil || result.Status != HookSucceeded || result.Attempts != 1 {
+		t.Fatalf("Expected success on the first attempt of the second run, got %s after %d, err %v", result.Status, result.Attempts, err)
+	}
+}
+
+func TestRunner_ContinueOnError(t *testing.T) {
+	runner := NewRunnerWithOptions(false, t.TempDir(), false, false)
+
+	result, err := runner.RunWithOptions(context.Background(), "testtool", HookTypePostinstall, "exit 1", Options{ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("Expected continue_on_error to tolerate the failure, got %v", err)
+	}
+	if !result.ContinuedOnError || result.Error == nil || result.Status != HookFailed {
+		t.Errorf("Expected the failure in the result, got %+v", result)
+	}
+
+	// The failed hook is not recorded, so it runs again next time
+	shouldRun, _, err := runner.stateMgr.ShouldRunHook("testtool", string(HookTypePostinstall), "exit 1")
+	if err != nil || !shouldRun {
+		t.Errorf("Expected no state for the failed hook, got shouldRun=%v err=%v", shouldRun, err)
+	}
+}
+
+func TestRunner_Shell(t *testing.T) {
+	runner := NewRunnerWithOptions(false, t.TempDir(), true, false)
+	ctx := context.Background()
+
+	// bash runs with pipefail, unlike sh -c
+	if _, err := runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, "false | true", Options{Shell: config.Shell{"bash"}}); err == nil {
+		t.Error("Expected bash to fail a failing pipeline")
+	}
+	if _, err := runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, "false | true", Options{}); err != nil {
+		t.Errorf("Expected sh -c to ignore a failing pipeline, got %v", err)
+	}
+
+	// A custom command gets the script file as {0}
+	result, err := runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, "echo from-file", Options{Shell: config.ParseShell("sh -x {0}")})
+	if err != nil {
+		t.Fatalf("Custom shell failed: %v", err)
+	}
+	if result.Stdout != "from-file\n" || len(result.Command) != 3 || result.Command[1] != "-x" {
+		t.Errorf("Unexpected result of custom shell: %q %v", result.Stdout, result.Command)
+	}
+	if _, err := os.Stat(result.Command[2]); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary script %s to be removed", result.Command[2])
+	}
+
+	// A list is used verbatim: arguments may contain spaces
+	result, err = runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, "echo sourced", Options{Shell: config.Shell{"sh", "-c", ". {0} && echo done"}})
+	if err != nil {
+		t.Fatalf("List shell failed: %v", err)
+	}
+	if result.Stdout != "sourced\ndone\n" {
+		t.Errorf("Expected the list argv to run unsplit, got %q (%v)", result.Stdout, result.Command)
+	}
+
+	if _, err := runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, "true", Options{Shell: config.Shell{"fish"}}); err == nil {
+		t.Error("Expected an unknown shell to fail")
+	}
+}
+
+func TestRunner_File(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewRunnerWithOptions(false, filepath.Join(dir, "state"), false, false)
+	ctx := context.Background()
+
+	file := filepath.Join(dir, "setup.sh")
+	run := func(content string) *HookResult {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, content, Options{Shell: config.Shell{"bash"}, File: file})
+		if err != nil {
+			t.Fatalf("Hook file failed: %v", err)
+		}
+		return result
+	}
+
+	if result := run("echo \"$0\"\n"); result.Skipped || result.Stdout != file+"\n" {
+		t.Errorf("Expected the file itself to run, got %+v", result)
+	}
+	if result := run("echo \"$0\"\n"); !result.Skipped {
+		t.Error("Expected an unchanged file to be skipped")
+	}
+	if result := run("echo edited\n"); result.Skipped || result.Stdout != "edited\n" {
+		t.Errorf("Expected an edited file to run again, got %+v", result)
+	}
+}
+
+func TestRunner_EnvAndCwd(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewRunnerWithOptions(false, filepath.Join(dir, "state"), false, false)
+	ctx := context.Background()
+
+	hook := config.Hook{Run: `echo "$(pwd) $GREETING"`, Cwd: dir, Env: map[string]string{"GREETING": "hook"}}
+	run := func(env map[string]string) *HookResult {
+		t.Helper()
+		result, err := runner.RunWithOptions(ctx, "testtool", HookTypePostinstall, hook.Run, HookOptions(hook, env))
+		if err != nil {
+			t.Fatalf("Hook failed: %v", err)
+		}
+		return result
+	}
+
+	// The hook's own env overrides the tool's
+	if result := run(map[string]string{"GREETING": "tool"}); result.Stdout != dir+" hook\n" {
+		t.Errorf("Expected the hook to run in %s with its env, got %q", dir, result.Stdout)
+	}
+	if result := run(map[string]string{"GREETING": "tool"}); !result.Skipped {
+		t.Error("Expected an unchanged hook to be skipped")
+	}
+	if result := run(map[string]string{"GREETING": "tool", "EXTRA": "1"}); result.Skipped {
+		t.Error("Expected a changed env to run the hook again")
+	}
+	hook.Env["GREETING"] = "changed"
+	if result := run(map[string]string{"GREETING": "tool", "EXTRA": "1"}); result.Skipped || result.Stdout != dir+" changed\n" {
+		t.Errorf("Expected a changed env value to run the hook again, got %+v", result)
+	}
+}
+
+func TestRunner_Guards(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewRunnerWithOptions(false, filepath.Join(dir, "state"), false, false)
+	ctx := context.Background()
+
+	marker := filepath.Join(dir, "lazygit")
+	script := "mkdir " + marker
+	opts := Options{Guards: Guards{Creates: marker}}
+	run := func(script string, opts Options) *HookResult {
+		t.Helper()
+		result, err := runner.RunWithOptions(ctx, "lazygit", HookTypePostinstall, script, opts)
+		if err != nil {
+			t.Fatalf("Hook failed: %v", err)
+		}
+		return result
+	}
+
+	if result := run(script, opts); result.Skipped {
+		t.Fatalf("Expected the hook to run while %s is missing", marker)
+	}
+	if result := run(script, opts); !result.Skipped || result.SkipReason != "creates: "+marker+" exists" {
+		t.Errorf("Expected the creates guard to skip the hook, got %+v", result)
+	}
+	// Deleting what the hook created runs it again, though its script is unchanged
+	if err := os.Remove(marker); err != nil {
+		t.Fatal(err)
+	}
+	if result := run(script, opts); result.Skipped {
+		t.Errorf("Expected the hook to run again once %s was deleted", marker)
+	}
+
+	for _, tt := range []struct {
+		guards Guards
+		reason string
+	}{
+		{Guards{Removes: filepath.Join(dir, "missing")}, "removes: " + filepath.Join(dir, "missing") + " does not exist"},
+		{Guards{Unless: "true"}, "unless: true succeeded"},
+		{Guards{OnlyIf: "test -n \"$FEATURE\""}, "only_if: test -n \"$FEATURE\" failed"},
+	} {
+		if result := run("echo guarded", Options{Guards: tt.guards}); !result.Skipped || result.SkipReason != tt.reason {
+			t.Errorf("Guards %+v: expected skip reason %q, got %+v", tt.guards, tt.reason, result)
+		}
+	}
+
+	// only_if runs in the hook's env; the state marker still applies
+	opts = Options{Env: map[string]string{"FEATURE": "1"}, Guards: Guards{OnlyIf: "test -n \"$FEATURE\""}}
+	if result := run("echo feature", opts); result.Skipped {
+		t.Error("Expected a passing only_if to run the hook")
+	}
+	if result := run("echo feature", opts); !result.Skipped || result.SkipReason != SkipStateUnchanged {
+		t.Errorf("Expected an unchanged hook with only_if to be skipped by its state, got %+v", result)
+	}
+}
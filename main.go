@@ -2,13 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/history"
 	"github.com/mise-seq/config-loader/hooks"
 	"github.com/mise-seq/config-loader/mise"
+	"github.com/mise-seq/config-loader/proc"
+	"github.com/mise-seq/config-loader/report"
+	"github.com/mise-seq/config-loader/runlog"
+	"github.com/mise-seq/config-loader/tracing"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -17,26 +29,146 @@ var (
 	date    = "unknown"
 )
 
+// cliOptions holds the flags accepted before and after the subcommand
+type cliOptions struct {
+	configPath          string
+	dryRun              bool
+	forceHooks          bool
+	postinstallOnUpdate bool
+	verbose             bool
+	showVersion         bool
+	stateDir            string
+	tags                string
+	skipTags            string
+	keepGoing           bool
+	atomic              bool
+	resume              bool
+	planFile            string
+	output              string
+	reports             reportTargets
+	traces              traceTargets
+	metricsFile         string
+	ci                  bool
+	logFormat           string
+	logFile             string
+	logDir              string
+	logRetention        string
+}
+
+// reportTargets collects repeated --report format=path flags
+type reportTargets []report.Target
+
+func (r *reportTargets) String() string {
+	parts := make([]string, len(*r))
+	for i, t := range *r {
+		parts[i] = t.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r *reportTargets) Set(value string) error {
+	target, err := report.ParseTarget(value)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, target)
+	return nil
+}
+
+// traceTargets collects repeated --trace format=path flags
+type traceTargets []tracing.Target
+
+func (t *traceTargets) String() string {
+	parts := make([]string, len(*t))
+	for i, target := range *t {
+		parts[i] = target.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (t *traceTargets) Set(value string) error {
+	target, err := tracing.ParseTarget(value)
+	if err != nil {
+		return err
+	}
+	*t = append(*t, target)
+	return nil
+}
+
+// register defines the flags on fs, using the current values as defaults so
+// that flags given before the subcommand are kept
+func (o *cliOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "c", o.configPath, "Path to config file")
+	fs.BoolVar(&o.dryRun, "dry-run", o.dryRun, "Dry run mode")
+	fs.BoolVar(&o.forceHooks, "force-hooks", o.forceHooks, "Force hook execution")
+	fs.BoolVar(&o.postinstallOnUpdate, "postinstall-on-update", o.postinstallOnUpdate, "Run postinstall on update")
+	fs.BoolVar(&o.verbose, "v", o.verbose, "Verbose output")
+	fs.BoolVar(&o.showVersion, "version", o.showVersion, "Show version")
+	fs.StringVar(&o.stateDir, "state-dir", o.stateDir, "Custom state directory")
+	fs.StringVar(&o.tags, "tags", o.tags, "Only act on tools with these tags or groups (comma-separated)")
+	fs.StringVar(&o.skipTags, "skip-tags", o.skipTags, "Skip tools with these tags or groups (comma-separated)")
+	fs.BoolVar(&o.keepGoing, "keep-going", o.keepGoing, "Continue with independent tools after a failure")
+	fs.BoolVar(&o.resume, "resume", o.resume, "Resume an interrupted install, skipping the tools and hooks it completed")
+	fs.BoolVar(&o.atomic, "atomic", o.atomic, "Restore the mise global config and active versions if a required tool or hook fails")
+	fs.StringVar(&o.planFile, "o", o.planFile, "Write the plan to this file (plan)")
+	fs.StringVar(&o.output, "output", o.output, "Output format: table, json or yaml")
+	fs.StringVar(&o.logFormat, "log-format", o.logFormat, "Log format on stderr: text or json")
+	fs.StringVar(&o.logFile, "log-file", o.logFile, "Also write debug-level JSON logs to this file")
+	fs.StringVar(&o.logDir, "log-dir", o.logDir, "Directory of per-run step logs")
+	fs.StringVar(&o.logRetention, "log-retention", o.logRetention, "Step logs to keep: a number of runs or an age (72h, 7d)")
+	fs.BoolVar(&o.ci, "ci", o.ci, "CI log mode: collapsible groups, annotations and token masking")
+	fs.Var(&o.reports, "report", "Write a report file, as format=path (json, yaml, junit, markdown; repeatable)")
+	fs.StringVar(&o.metricsFile, "metrics-file", o.metricsFile, "Write Prometheus textfile-collector metrics to this file")
+	fs.Var(&o.traces, "trace", "Export a timing trace, as format=path (chrome, otlp; otlp also to an http:// collector; repeatable)")
+}
+
+// selection builds the tool selection from flags and positional tool arguments
+func (o *cliOptions) selection(toolArgs []string) config.Selection {
+	return config.Selection{
+		Tools:    toolArgs,
+		Tags:     splitList(o.tags),
+		SkipTags: splitList(o.skipTags),
+	}
+}
+
+// splitList splits a comma-separated flag value
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseInterspersed parses flags that may appear between positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 func main() {
 	// Parse global flags
-	configPath := flag.String("c", "tools.yaml", "Path to config file")
-	dryRun := flag.Bool("dry-run", false, "Dry run mode")
-	forceHooks := flag.Bool("force-hooks", false, "Force hook execution")
-	postinstallOnUpdate := flag.Bool("postinstall-on-update", false, "Run postinstall on update")
-	verbose := flag.Bool("v", false, "Verbose output")
-	showVersion := flag.Bool("version", false, "Show version")
-	stateDir := flag.String("state-dir", "", "Custom state directory")
+	// CI mode is on by default when a CI system is detected
+	ciProvider := mise.DetectCI(os.Getenv)
+	opts := &cliOptions{configPath: "tools.yaml", output: "table", logFormat: "text", ci: ciProvider != mise.CINone}
+	opts.register(flag.CommandLine)
 
 	flag.Parse()
 
 	args := flag.Args()
 
-	// Handle version flag early
-	if *showVersion {
-		fmt.Printf("mise-seq version %s (commit: %s, date: %s)\n", version, commit, date)
-		os.Exit(0)
-	}
-
 	// Determine subcommand
 	subcommand := "install" // default
 	if len(args) > 0 {
@@ -45,7 +177,7 @@ func main() {
 	}
 
 	// Validate subcommand
-	validSubcommands := []string{"install", "upgrade", "list", "status", "help"}
+	validSubcommands := []string{"install", "upgrade", "list", "status", "plan", "apply", "fmt", "logs", "history", "rollback", "help"}
 	valid := false
 	for _, v := range validSubcommands {
 		if subcommand == v {
@@ -53,12 +185,26 @@ func main() {
 			break
 		}
 	}
-	if !valid {
+	if !valid && !opts.showVersion {
 		fmt.Fprintf(os.Stderr, "Error: unknown subcommand '%s'\n", subcommand)
 		fmt.Fprintf(os.Stderr, "Run 'mise-seq help' for usage.\n")
 		os.Exit(1)
 	}
 
+	// Parse command flags and positional tool arguments
+	fs := flag.NewFlagSet(subcommand, flag.ExitOnError)
+	opts.register(fs)
+	toolArgs, err := parseInterspersed(fs, args)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	// Handle version flag early
+	if opts.showVersion {
+		fmt.Printf("mise-seq version %s (commit: %s, date: %s)\n", version, commit, date)
+		os.Exit(0)
+	}
+
 	// Handle help
 	if subcommand == "help" {
 		printHelp()
@@ -66,83 +212,647 @@ func main() {
 	}
 
 	// Initialize logger
-	config.InitLogger(*verbose)
+	logFormat, err := config.ParseLogFormat(opts.logFormat)
+	if err != nil {
+		config.Error("%v", err)
+		os.Exit(2)
+	}
+	logLevel := config.LogLevelInfo
+	if opts.verbose {
+		logLevel = config.LogLevelDebug
+	}
+	if err := config.InitLoggerWithOptions(config.LoggerOptions{Level: logLevel, Format: logFormat, File: opts.logFile, Mask: config.SecretValues(os.Environ())}); err != nil {
+		config.Error("%v", err)
+		os.Exit(1)
+	}
+
+	format, err := report.ParseFormat(opts.output)
+	if err != nil {
+		config.Error("%v", err)
+		os.Exit(2)
+	}
+	if format != report.FormatTable && subcommand == "fmt" {
+		config.Error("--output %s is not supported for %s", format, subcommand)
+		os.Exit(2)
+	}
+	if len(opts.reports) > 0 && (subcommand == "plan" || subcommand == "fmt") {
+		config.Error("--report is not supported for %s", subcommand)
+		os.Exit(2)
+	}
+	if opts.metricsFile != "" && subcommand != "install" && subcommand != "upgrade" && subcommand != "status" {
+		config.Error("--metrics-file is not supported for %s", subcommand)
+		os.Exit(2)
+	}
+	if opts.resume && subcommand != "install" {
+		config.Error("--resume is not supported for %s", subcommand)
+		os.Exit(2)
+	}
+	if opts.atomic && subcommand != "install" && subcommand != "upgrade" {
+		config.Error("--atomic is not supported for %s", subcommand)
+		os.Exit(2)
+	}
+
+	// With a machine-readable format only the report goes to stdout;
+	// progress text goes to stderr
+	rep := report.New(subcommand)
+	rep.Config = opts.configPath
+	rep.RunID = runlog.NewRunID(rep.StartedAt)
+	textOut := io.Writer(os.Stdout)
+	if format != report.FormatTable {
+		textOut = os.Stderr
+	}
+	// Spans are recorded into rec and exported on exit
+	rec := tracing.NewRecorder()
+	var rootSpan *tracing.Span
+	// journal records the finished run in the history, once it may have
+	// changed the machine
+	var journal func()
+	// The first SIGINT or SIGTERM cancels sigCtx and is forwarded to the
+	// running mise or hook; the run then ends with a partial summary
+	sigCtx, _ := proc.NotifyContext(context.Background())
+	exit := func(err error, code int) {
+		if interrupt := proc.Interrupted(sigCtx); interrupt != nil && code != mise.ExitOK {
+			code = mise.ExitInterrupted
+			rep.Interrupted = interrupt.SignalName()
+		}
+		if err != nil {
+			config.Error("%v", err)
+		}
+		rep.Finish(err, code)
+		if journal != nil {
+			journal()
+		}
+		rootSpan.SetAttr("exit_code", code)
+		rootSpan.Finish(err)
+		for _, target := range opts.traces {
+			if werr := rec.Export(context.Background(), target); werr != nil {
+				config.Warn("Failed to export trace: %v", werr)
+			}
+		}
+		if format != report.FormatTable && subcommand != "plan" {
+			if werr := rep.Write(os.Stdout, format); werr != nil {
+				config.Error("Failed to write report: %v", werr)
+			}
+		}
+		for _, target := range opts.reports {
+			if werr := rep.WriteFile(target); werr != nil {
+				config.Error("%v", werr)
+			}
+		}
+		if opts.metricsFile != "" {
+			if werr := rep.WriteMetricsFile(opts.metricsFile); werr != nil {
+				config.Error("%v", werr)
+			}
+		}
+		config.CloseLogger()
+		os.Exit(code)
+	}
+
+	// Handle fmt (rewrites the config file, no mise required)
+	if subcommand == "fmt" {
+		if err := runFmt(opts.configPath); err != nil {
+			config.Error("%v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Handle logs (reads the step logs of the last run, no mise required)
+	if subcommand == "logs" {
+		if len(toolArgs) > 1 {
+			config.Error("logs takes at most one tool: mise-seq logs [tool]")
+			os.Exit(1)
+		}
+		tool := ""
+		if len(toolArgs) == 1 {
+			tool = toolArgs[0]
+		}
+		if err := runLogs(os.Stdout, opts.logDirOr(config.LoadRuntimeConfig()), tool); err != nil {
+			config.Error("%v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Handle history (reads the run journal, no mise required)
+	if subcommand == "history" {
+		if len(toolArgs) > 1 {
+			config.Error("history takes at most one run id: mise-seq history [run-id]")
+			os.Exit(1)
+		}
+		id := ""
+		if len(toolArgs) == 1 {
+			id = toolArgs[0]
+		}
+		if err := runHistory(os.Stdout, opts.stateDirOr(config.LoadRuntimeConfig()), id, format); err != nil {
+			config.Error("%v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(toolArgs) > 0 && subcommand == "list" {
+		exit(fmt.Errorf("list does not take tool arguments; use --tags to filter"), 1)
+	}
+	if subcommand == "apply" && len(toolArgs) != 1 {
+		exit(fmt.Errorf("apply takes exactly one plan file: mise-seq apply plan.json"), 1)
+	}
+	if subcommand == "rollback" && len(toolArgs) != 1 {
+		exit(fmt.Errorf("rollback takes exactly one run id: mise-seq rollback <run-id>"), 1)
+	}
 
 	// Load runtime config
 	runtimeCfg := config.LoadRuntimeConfig()
-	runtimeCfg.DryRun = *dryRun
-	runtimeCfg.ForceHooks = *forceHooks
-	runtimeCfg.RunPostinstallOnUpdate = *postinstallOnUpdate
-	if *stateDir != "" {
-		runtimeCfg.StateDir = *stateDir
+	runtimeCfg.DryRun = opts.dryRun
+	runtimeCfg.ForceHooks = opts.forceHooks
+	runtimeCfg.RunPostinstallOnUpdate = opts.postinstallOnUpdate
+	if opts.stateDir != "" {
+		runtimeCfg.StateDir = opts.stateDir
+	}
+	runtimeCfg.LogDir = opts.logDirOr(runtimeCfg)
+	if opts.logRetention != "" {
+		runtimeCfg.LogRetention = opts.logRetention
 	}
-	if *verbose {
+	retention, err := runlog.ParseRetention(runtimeCfg.LogRetention)
+	if err != nil {
+		exit(err, 2)
+	}
+	rep.StateDir = runtimeCfg.StateDir
+	stateDir := opts.stateDirOr(runtimeCfg)
+	if opts.verbose {
 		runtimeCfg.Debug = true
 	}
 
-	ctx := context.Background()
+	ctx := sigCtx
+	if len(opts.traces) > 0 {
+		ctx = tracing.WithRecorder(ctx, rec)
+		ctx, rootSpan = tracing.Start(ctx, "mise-seq "+subcommand, tracing.Attr{Key: "command", Value: subcommand})
+	}
 
 	// Setup environment
 	if err := runtimeCfg.SetupEnvironment(); err != nil {
-		config.Error("Failed to setup environment: %v", err)
-		os.Exit(1)
+		exit(fmt.Errorf("failed to setup environment: %w", err), 1)
 	}
 
 	// Bootstrap
 	bootstrapper := mise.NewBootstrapper()
 	bootstrapper.SetVersion(runtimeCfg.CUEVersion)
+	bootstrapCtx, bootstrapSpan := tracing.Start(ctx, "bootstrap")
 
-	if err := bootstrapper.EnsureMise(ctx); err != nil {
-		config.Error("mise is required but not found")
+	if err := bootstrapper.EnsureMise(bootstrapCtx); err != nil {
+		bootstrapSpan.Finish(err)
 		config.Error("Please install mise: https://github.com/jdx/mise")
-		os.Exit(1)
+		exit(fmt.Errorf("mise is required but not found"), 1)
 	}
 
-	if err := bootstrapper.EnsureCue(ctx); err != nil {
+	if err := bootstrapper.EnsureCue(bootstrapCtx); err != nil {
 		config.Warn("cue not available: %v", err)
 		// Continue without CUE support
 	}
+	bootstrapSpan.Finish(nil)
+
+	miseClient := mise.NewClient()
+	rep.Platform = miseClient.Platform().String()
+
+	// Apply executes a saved plan and does not read the config; it reports
+	// like install
+	if subcommand == "apply" {
+		plan, err := mise.ReadPlan(toolArgs[0])
+		if err != nil {
+			exit(err, 1)
+		}
+		rep.Config = plan.ConfigPath
+		installerOpts := []mise.InstallerOption{
+			mise.WithObserver(progressObserver(textOut, opts.ci, ciProvider, opts.verbose)),
+			mise.WithObserver(rep),
+		}
+		capture := startCapture(runtimeCfg.LogDir, rep.RunID, retention)
+		if capture != nil {
+			installerOpts = append(installerOpts, mise.WithObserver(capture))
+		}
+		journal = startJournal(ctx, miseClient, stateDir, rep, plan.ConfigHash, "", opts.dryRun)
+		summary, err := runApply(ctx, mise.NewInstaller(miseClient, installerOpts...), toolArgs[0], plan)
+		rep.ApplySummary(summary)
+		code := summaryExitCode(textOut, summary, err)
+		showCapture(capture, code, opts.verbose)
+		exit(err, code)
+	}
+
+	// Rollback re-activates the versions from before a past run and does not
+	// read the config
+	if subcommand == "rollback" {
+		target, err := history.Load(stateDir, toolArgs[0])
+		if err != nil {
+			exit(err, 1)
+		}
+		journal = startJournal(ctx, miseClient, stateDir, rep, "", target.ID, opts.dryRun)
+		config.Info("=== Rolling back %s ===", target.ID)
+		if err := history.Rollback(ctx, miseClient, textOut, target, rep); err != nil {
+			exit(err, mise.ExitFailed)
+		}
+		exit(nil, mise.ExitOK)
+	}
 
 	// Load config
-	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
-		config.Error("Config file not found: %s", *configPath)
-		os.Exit(1)
+	if _, err := os.Stat(opts.configPath); os.IsNotExist(err) {
+		exit(fmt.Errorf("config file not found: %s", opts.configPath), 1)
 	}
 
+	_, loadSpan := tracing.Start(ctx, "load config", tracing.Attr{Key: "path", Value: opts.configPath})
 	loader := config.NewLoader()
-	cfg, err := loader.Parse(*configPath)
+	cfg, err := loader.Parse(opts.configPath)
 	if err != nil {
-		config.Error("Failed to load config: %v", err)
-		os.Exit(1)
+		loadSpan.Finish(err)
+		exit(fmt.Errorf("failed to load config: %w", err), 1)
 	}
 
 	// Validate config
 	if err := config.ValidateConfig(cfg); err != nil {
-		config.Error("Invalid config: %v", err)
-		os.Exit(1)
+		loadSpan.Finish(err)
+		exit(fmt.Errorf("invalid config: %w", err), 1)
 	}
+	loadSpan.Finish(nil)
 
-	miseClient := mise.NewClient()
+	// Leave out disabled tools and tools and hooks for other platforms
+	platform := miseClient.Platform()
+	cfg, excluded := config.FilterDisabled(cfg)
+	cfg, unsupported := config.FilterForPlatform(cfg, platform)
+	excluded = append(excluded, unsupported...)
+	if opts.verbose {
+		config.Info("Platform: %s (distro: %s)", platform, platform.Distro)
+	}
+
+	// Narrow down to the selected tools (plus their dependencies)
+	for _, name := range toolArgs {
+		for _, ex := range excluded {
+			if ex.Tool == name {
+				exit(fmt.Errorf("tool '%s' is excluded: %s", name, ex.Reason), 1)
+			}
+		}
+	}
+	cfg, err = config.Select(cfg, opts.selection(toolArgs))
+	if err != nil {
+		exit(err, 1)
+	}
+
+	installOpts := mise.InstallOptions{
+		KeepGoing:              opts.keepGoing,
+		RunPostinstallOnUpdate: runtimeCfg.RunPostinstallOnUpdate,
+		StateDir:               runtimeCfg.StateDir,
+		ForceHooks:             runtimeCfg.ForceHooks,
+	}
+
+	// The CLI text output and the report are both observers of the installer's events
+	installerOpts := []mise.InstallerOption{
+		mise.WithOptions(installOpts),
+		mise.WithObserver(progressObserver(textOut, opts.ci, ciProvider, opts.verbose)),
+		mise.WithObserver(rep),
+	}
+
+	// Install checkpoints and the history are keyed by the config file's hash
+	var configHash string
+	if subcommand == "install" || subcommand == "upgrade" {
+		if configHash, err = config.HashFile(opts.configPath); err != nil {
+			config.Warn("%v", err)
+		}
+	}
+	if subcommand == "install" {
+		installerOpts = append(installerOpts, mise.WithCheckpoint(configHash, opts.resume))
+	}
+
+	// Keep the output of every mise command and hook of install and upgrade
+	var capture *runlog.Capture
+	if subcommand == "install" || subcommand == "upgrade" {
+		if capture = startCapture(runtimeCfg.LogDir, rep.RunID, retention); capture != nil {
+			installerOpts = append(installerOpts, mise.WithObserver(capture))
+		}
+	}
+	installer := mise.NewInstaller(miseClient, installerOpts...)
+
+	// list and status print nothing but the report in machine-readable mode
+	listOut := textOut
+	if format != report.FormatTable {
+		listOut = io.Discard
+	}
+
+	if subcommand == "install" || subcommand == "upgrade" {
+		journal = startJournal(ctx, miseClient, stateDir, rep, configHash, "", opts.dryRun)
+	}
+
+	// With --atomic a failed run is undone; without a snapshot it may not run
+	var snapshot *mise.Snapshot
+	if opts.atomic && !opts.dryRun {
+		if snapshot, err = miseClient.Snapshot(ctx); err != nil {
+			exit(fmt.Errorf("cannot run atomically: %w", err), 1)
+		}
+	}
 
 	// Execute subcommand
+	var summary *mise.Summary
 	switch subcommand {
 	case "install":
-		err = runInstall(ctx, cfg, miseClient, runtimeCfg, *verbose, *dryRun)
+		summary, err = runInstall(ctx, cfg, miseClient, installer, runtimeCfg, opts.verbose, opts.dryRun)
 	case "upgrade":
-		err = runUpgrade(ctx, cfg, miseClient, runtimeCfg, *verbose, *dryRun)
+		summary, err = runUpgrade(ctx, cfg, installer, opts.verbose)
 	case "list":
-		err = runList(ctx, cfg, miseClient, *verbose)
+		err = runList(ctx, listOut, cfg, miseClient, excluded, opts.verbose)
 	case "status":
-		err = runStatus(ctx, cfg, miseClient, *verbose)
+		err = runStatus(ctx, listOut, rep, cfg, miseClient, excluded, runtimeCfg.StateDir)
+	case "plan":
+		err = runPlan(ctx, textOut, cfg, miseClient, installOpts, opts.configPath, opts.planFile, format)
+	}
+
+	// Recording the outcome of an interrupted run must not be interrupted
+	ctx = context.WithoutCancel(ctx)
+
+	rep.ApplySummary(summary)
+	rep.AddConfig(cfg)
+	rep.AddExcluded(excluded)
+	if opts.metricsFile != "" && summary != nil && !opts.dryRun {
+		// Metrics carry the versions installed after the run
+		if inv, ierr := miseClient.Inventory(ctx); ierr == nil {
+			rep.AddInventory(inv)
+		} else {
+			config.Warn("Installed versions not in metrics: %v", ierr)
+		}
+	}
+
+	code := summaryExitCode(textOut, summary, err)
+	if snapshot != nil && code != mise.ExitOK {
+		restoreSnapshot(ctx, textOut, miseClient, snapshot, rep)
+	}
+	showCapture(capture, code, opts.verbose)
+	exit(err, code)
+}
+
+// progressObserver returns the observer printing the installer's progress:
+// plain text, or CI log groups and annotations with --ci. --ci outside a
+// known CI system uses GitHub workflow commands.
+func progressObserver(w io.Writer, ci bool, provider mise.CIProvider, verbose bool) mise.Observer {
+	if !ci {
+		return mise.NewTextObserver(w, os.Stderr, verbose)
+	}
+	if provider == mise.CINone {
+		provider = mise.CIGitHub
 	}
+	return mise.NewCIObserver(provider, w, os.Stderr, verbose)
+}
 
+// summaryExitCode prints the summary of a run, if any, and returns the exit
+// code of the run
+func summaryExitCode(w io.Writer, summary *mise.Summary, err error) int {
+	code := mise.ExitOK
+	if summary != nil {
+		summary.Print(w)
+		code = summary.ExitCode()
+	}
 	if err != nil {
-		config.Error("%v", err)
-		os.Exit(1)
+		code = mise.ExitFailed
+	}
+	return code
+}
+
+// showCapture warns about step logs that could not be written and points to
+// the step logs of a failed or verbose run
+func showCapture(capture *runlog.Capture, code int, verbose bool) {
+	if capture == nil {
+		return
+	}
+	if err := capture.Err(); err != nil {
+		config.Warn("%v", err)
+	}
+	if code != mise.ExitOK || verbose {
+		config.Info("Step logs: %s (mise-seq logs [tool])", capture.Dir())
+	}
+}
+
+// logDirOr returns --log-dir, or the log directory of runtimeCfg
+func (o *cliOptions) logDirOr(runtimeCfg *config.RuntimeConfig) string {
+	if o.logDir != "" {
+		return o.logDir
+	}
+	return runtimeCfg.LogDir
+}
+
+// restoreSnapshot undoes a failed --atomic run. The run still fails; a
+// failed restore is reported as an error.
+func restoreSnapshot(ctx context.Context, w io.Writer, client *mise.Client, snapshot *mise.Snapshot, rep *report.Report) {
+	config.Warn("Run failed; restoring %s and the active tool versions (--atomic)", snapshot.ConfigFile)
+	reverted, err := client.Restore(ctx, snapshot)
+	for _, r := range reverted {
+		fmt.Fprintf(w, "  reverted %s\n", r)
+	}
+	if err != nil {
+		config.Error("Restore incomplete: %v", err)
+		return
+	}
+	rep.Restored = true
+	config.Info("Restored mise global config")
+}
+
+// stateDirOr returns --state-dir, or the state directory of runtimeCfg, or
+// the default hook state directory
+func (o *cliOptions) stateDirOr(runtimeCfg *config.RuntimeConfig) string {
+	if o.stateDir != "" {
+		return o.stateDir
+	}
+	if runtimeCfg.StateDir != "" {
+		return runtimeCfg.StateDir
+	}
+	return hooks.NewStateManager().StateDir
+}
+
+// startJournal snapshots the installed versions before a run and returns the
+// function that records the run in the history when it ends. Dry runs change
+// nothing and are not recorded; failing to record is a warning.
+func startJournal(ctx context.Context, client *mise.Client, stateDir string, rep *report.Report, configHash, rollbackOf string, dryRun bool) func() {
+	if dryRun {
+		return nil
+	}
+	before, err := client.Inventory(ctx)
+	if err != nil {
+		config.Warn("Run not recorded in history: %v", err)
+		return nil
+	}
+	return func() {
+		after, err := client.Inventory(context.WithoutCancel(ctx))
+		if err != nil {
+			config.Warn("Run not recorded in history: %v", err)
+			return
+		}
+		entry := history.New(rep, configHash, before, after)
+		entry.RollbackOf = rollbackOf
+		if err := history.Save(stateDir, entry); err != nil {
+			config.Warn("%v", err)
+		}
+	}
+}
+
+// runHistory lists the recorded runs, or shows one run
+func runHistory(w io.Writer, stateDir, id string, format report.Format) error {
+	if id != "" {
+		entry, err := history.Load(stateDir, id)
+		if err != nil {
+			return err
+		}
+		if format != report.FormatTable {
+			return writeDocument(w, entry, format)
+		}
+		printHistoryEntry(w, entry)
+		return nil
+	}
+
+	entries, err := history.List(stateDir)
+	if err != nil {
+		return err
 	}
+	if format != report.FormatTable {
+		return writeDocument(w, entries, format)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "No runs recorded in %s\n", history.Dir(stateDir))
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\tCOMMAND\tSTARTED\tRESULT\tCHANGES")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.ID, e.Command, e.StartedAt.Local().Format("2006-01-02 15:04:05"), historyResult(e), e.Changes())
+	}
+	return tw.Flush()
+}
+
+// printHistoryEntry prints one recorded run
+func printHistoryEntry(w io.Writer, e *history.Entry) {
+	fmt.Fprintf(w, "Run:       %s\n", e.ID)
+	fmt.Fprintf(w, "Command:   %s\n", e.Command)
+	if e.RollbackOf != "" {
+		fmt.Fprintf(w, "Rollback:  of %s\n", e.RollbackOf)
+	}
+	if e.Config != "" {
+		fmt.Fprintf(w, "Config:    %s (sha256 %s)\n", e.Config, e.ConfigHash)
+	}
+	fmt.Fprintf(w, "Started:   %s\n", e.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Duration:  %s\n", e.FinishedAt.Sub(e.StartedAt).Round(time.Millisecond))
+	fmt.Fprintf(w, "Result:    %s (exit code %d)\n\n", historyResult(e), e.ExitCode)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tACTION\tSTATUS\tBEFORE\tAFTER\tHOOKS")
+	for _, t := range e.Tools {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Name, orDash(t.Action), orDash(t.Status), orDash(t.Before), orDash(t.After), hookCounts(t.Hooks))
+	}
+	tw.Flush()
+
+	for _, t := range e.Tools {
+		for _, h := range t.Hooks {
+			if h.Error != "" {
+				fmt.Fprintf(w, "\n%s %s hook failed: %s\n  %s\n", t.Name, h.Type, h.Error, strings.ReplaceAll(h.Script, "\n", "\n  "))
+			}
+		}
+	}
+	if len(e.Changed()) > 0 {
+		fmt.Fprintf(w, "\nUndo with: mise-seq rollback %s\n", e.ID)
+	}
+}
+
+// historyResult describes the outcome of a recorded run
+func historyResult(e *history.Entry) string {
+	if e.Success {
+		return "succeeded"
+	}
+	return "failed"
+}
+
+// hookCounts summarizes hook results, e.g. "2 run, 1 failed, 1 skipped"
+func hookCounts(hookResults []history.Hook) string {
+	if len(hookResults) == 0 {
+		return "-"
+	}
+	var run, failed, skipped int
+	for _, h := range hookResults {
+		switch {
+		case h.Skipped:
+			skipped++
+		case h.Error != "":
+			failed++
+		default:
+			run++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d run", run)}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", failed))
+	}
+	if skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", skipped))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// startCapture creates the step log directory of this run and removes old
+// runs. Failing to keep logs is a warning, not an error.
+func startCapture(logDir, runID string, retention runlog.Retention) *runlog.Capture {
+	capture, err := runlog.Start(logDir, runID)
+	if err != nil {
+		config.Warn("Step logs disabled: %v", err)
+		return nil
+	}
+	if _, err := runlog.Prune(logDir, retention); err != nil {
+		config.Warn("%v", err)
+	}
+	return capture
 }
 
-func runInstall(ctx context.Context, cfg *config.Config, client *mise.Client, runtimeCfg *config.RuntimeConfig, verbose, dryRun bool) error {
+// runLogs prints the step logs of the last run: an index of all steps, or
+// the full logs of one tool
+func runLogs(w io.Writer, logDir, tool string) error {
+	runDir, err := runlog.Latest(logDir)
+	if err != nil {
+		return err
+	}
+	entries, err := runlog.Entries(runDir, tool)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		if tool != "" {
+			return fmt.Errorf("no logs for '%s' in %s", tool, runDir)
+		}
+		return fmt.Errorf("no logs in %s", runDir)
+	}
+
+	fmt.Fprintf(w, "Run: %s\n", runDir)
+	if tool == "" {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TOOL\tPHASE\tEXIT\tDURATION\tCOMMAND")
+		for _, e := range entries {
+			exitCode := fmt.Sprint(e.ExitCode)
+			if e.Skipped {
+				exitCode = "skipped"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Tool, e.Phase, exitCode, e.Duration, e.Command)
+		}
+		return tw.Flush()
+	}
+
+	for _, e := range entries {
+		data, err := os.ReadFile(e.Path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n==> %s <==\n%s", e.Path, data)
+	}
+	return nil
+}
+
+func runInstall(ctx context.Context, cfg *config.Config, client *mise.Client, installer *mise.Installer, runtimeCfg *config.RuntimeConfig, verbose, dryRun bool) (*mise.Summary, error) {
 	config.Info("=== Installing tools ===")
 
 	// Apply settings
@@ -160,10 +870,9 @@ func runInstall(ctx context.Context, cfg *config.Config, client *mise.Client, ru
 		}
 		preinstall, _ := config.GetDefaultsHooks(cfg)
 		if len(preinstall) > 0 {
-			scripts := mise.ExtractHookScripts(preinstall)
 			hookRunner := hooks.NewRunnerWithOptions(dryRun, runtimeCfg.StateDir, runtimeCfg.ForceHooks, runtimeCfg.RunPostinstallOnUpdate)
 			hookRunner.SetVerbose(verbose)
-			_, err := hookRunner.RunDefaultsHook(ctx, hooks.HookTypePreinstall, scripts)
+			_, err := hookRunner.RunDefaults(ctx, hooks.HookTypePreinstall, preinstall, cfg.DefaultsEnv())
 			if err != nil {
 				config.Warn("Default preinstall hooks failed: %v", err)
 			}
@@ -174,44 +883,42 @@ func runInstall(ctx context.Context, cfg *config.Config, client *mise.Client, ru
 	if verbose {
 		config.Info("Installing tools...")
 	}
-	if err := client.InstallAllWithHooks(ctx, cfg, runtimeCfg.RunPostinstallOnUpdate); err != nil {
-		return fmt.Errorf("installation failed: %w", err)
+	summary, err := installer.Install(ctx, cfg)
+	if err != nil {
+		return summary, fmt.Errorf("installation failed: %w", err)
 	}
 
 	config.Info("Installation complete!")
-	return nil
+	return summary, nil
 }
 
-func runUpgrade(ctx context.Context, cfg *config.Config, client *mise.Client, runtimeCfg *config.RuntimeConfig, verbose, dryRun bool) error {
+func runUpgrade(ctx context.Context, cfg *config.Config, installer *mise.Installer, verbose bool) (*mise.Summary, error) {
 	config.Info("=== Upgrading tools ===")
 
 	// Get tools to upgrade
 	tools := config.GetTools(cfg)
 	if len(tools) == 0 {
 		config.Info("No tools configured")
-		return nil
+		return nil, nil
 	}
 
-	// Upgrade each tool
-	for toolName := range tools {
-		if verbose {
-			config.Info("Upgrading %s...", toolName)
-		}
-		_, err := client.UpgradeWithOutput(ctx, toolName)
-		if err != nil {
-			config.Warn("Failed to upgrade %s: %v", toolName, err)
-		}
+	if verbose {
+		config.Info("Upgrading %d tool(s)...", len(tools))
+	}
+	summary, err := installer.Upgrade(ctx, cfg)
+	if err != nil {
+		return summary, fmt.Errorf("upgrade failed: %w", err)
 	}
 
 	config.Info("Upgrade complete!")
-	return nil
+	return summary, nil
 }
 
-func runList(ctx context.Context, cfg *config.Config, client *mise.Client, verbose bool) error {
+func runList(ctx context.Context, w io.Writer, cfg *config.Config, client *mise.Client, excluded []config.Exclusion, verbose bool) error {
 	config.Info("=== Configured tools ===")
 
 	tools := config.GetTools(cfg)
-	if len(tools) == 0 {
+	if len(tools) == 0 && len(excluded) == 0 {
 		config.Info("No tools configured")
 		return nil
 	}
@@ -220,30 +927,32 @@ func runList(ctx context.Context, cfg *config.Config, client *mise.Client, verbo
 	order := config.GetToolOrder(cfg)
 
 	if len(order) > 0 {
-		fmt.Println("Installation order:")
+		fmt.Fprintln(w, "Installation order:")
 		for i, toolName := range order {
 			tool, ok := tools[toolName]
 			if !ok {
 				continue
 			}
-			fmt.Printf("  %d. %s @ %s\n", i+1, toolName, tool.Version)
+			fmt.Fprintf(w, "  %d. %s @ %s\n", i+1, toolName, versionLabel(tool))
 		}
 	} else {
-		fmt.Println("Tools:")
+		fmt.Fprintln(w, "Tools:")
 		for toolName, tool := range tools {
-			fmt.Printf("  - %s @ %s\n", toolName, tool.Version)
+			fmt.Fprintf(w, "  - %s @ %s\n", toolName, versionLabel(tool))
 		}
 	}
 
+	printExclusions(w, excluded)
+
 	// List installed tools
 	if verbose {
-		fmt.Println("\n=== Installed tools ===")
+		fmt.Fprintln(w, "\n=== Installed tools ===")
 		installed, err := client.ListTools(ctx)
 		if err != nil {
 			config.Warn("Failed to list installed tools: %v", err)
 		} else {
 			for _, t := range installed {
-				fmt.Printf("  - %s\n", t)
+				fmt.Fprintf(w, "  - %s\n", t)
 			}
 		}
 	}
@@ -251,55 +960,179 @@ func runList(ctx context.Context, cfg *config.Config, client *mise.Client, verbo
 	return nil
 }
 
-func runStatus(ctx context.Context, cfg *config.Config, client *mise.Client, verbose bool) error {
+func runStatus(ctx context.Context, w io.Writer, rep *report.Report, cfg *config.Config, client *mise.Client, excluded []config.Exclusion, stateDir string) error {
 	config.Info("=== Status ===")
 
 	tools := config.GetTools(cfg)
 
 	// Check which tools are managed by mise
-	installed, err := client.ListTools(ctx)
+	inv, err := client.Inventory(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list tools: %w", err)
-	}
-
-	installedMap := make(map[string]bool)
-	for _, t := range installed {
-		installedMap[t.Name] = true
+		return err
 	}
 
-	fmt.Println("Tools:")
+	fmt.Fprintln(w, "Tools:")
 	for toolName, tool := range tools {
-		version := tool.Version
-		if version == "" {
-			version = "latest"
-		}
+		version := versionLabel(tool)
 
+		installed := inv.Manages(toolName)
 		status := "not installed"
-		if installedMap[toolName] {
+		if installed {
 			status = "installed"
 		}
 
-		fmt.Printf("  %s @ %s [%s]\n", toolName, version, status)
+		fmt.Fprintf(w, "  %s @ %s [%s]\n", toolName, version, status)
+
+		t := rep.Tool(toolName)
+		t.Installed = &installed
+		t.InstalledVersion = inv.Active(toolName)
 	}
 
+	printExclusions(w, excluded)
+
 	// Show state directory info
 	stateMgr := hooks.NewStateManager()
-	fmt.Printf("\nState directory: %s\n", stateMgr.StateDir)
+	if stateDir != "" {
+		stateMgr.StateDir = stateDir
+	}
+	fmt.Fprintf(w, "\nState directory: %s\n", stateMgr.StateDir)
+	rep.StateDir = stateMgr.StateDir
 
 	return nil
 }
 
+func runPlan(ctx context.Context, w io.Writer, cfg *config.Config, client *mise.Client, opts mise.InstallOptions, configPath, planFile string, format report.Format) error {
+	config.Info("=== Planning ===")
+
+	plan, err := client.Plan(ctx, cfg, opts)
+	if err != nil {
+		return fmt.Errorf("planning failed: %w", err)
+	}
+	// Apply checks the config file is unchanged, from wherever it runs
+	if plan.ConfigPath, err = filepath.Abs(configPath); err != nil {
+		return err
+	}
+	if plan.ConfigHash, err = config.HashFile(plan.ConfigPath); err != nil {
+		return err
+	}
+
+	plan.Print(w)
+	if format != report.FormatTable {
+		if err := writeDocument(os.Stdout, plan, format); err != nil {
+			return err
+		}
+	}
+
+	if planFile == "" {
+		return nil
+	}
+	if err := mise.WritePlan(planFile, plan); err != nil {
+		return err
+	}
+	config.Info("Plan written to %s; run 'mise-seq apply %s' to execute it", planFile, planFile)
+	return nil
+}
+
+func runApply(ctx context.Context, installer *mise.Installer, planFile string, plan *mise.Plan) (*mise.Summary, error) {
+	config.Info("=== Applying %s ===", planFile)
+
+	summary, err := installer.Apply(ctx, plan)
+	if err != nil {
+		return summary, fmt.Errorf("apply failed: %w", err)
+	}
+
+	config.Info("Apply complete!")
+	return summary, nil
+}
+
+// writeDocument writes v as JSON or YAML; for plan and history, the plan or
+// journal itself is the machine-readable output
+func writeDocument(w io.Writer, v any, format report.Format) error {
+	if format == report.FormatYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printExclusions lists disabled tools and tools left out for this platform, and why
+func printExclusions(w io.Writer, excluded []config.Exclusion) {
+	if len(excluded) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nExcluded:")
+	for _, ex := range excluded {
+		fmt.Fprintf(w, "  - %s: %s\n", ex.Tool, ex.Reason)
+	}
+}
+
+// versionLabel formats a tool's version(s) for display, marking the default
+// of a versions list with "*" and optional tools with "(optional)"
+func versionLabel(tool config.Tool) string {
+	if tool.Optional {
+		tool.Optional = false
+		return versionLabel(tool) + " (optional)"
+	}
+
+	if !tool.HasMultipleVersions() {
+		if tool.Version == "" {
+			return "latest"
+		}
+		return string(tool.Version)
+	}
+
+	labels := make([]string, 0, len(tool.Versions))
+	for _, v := range tool.InstallVersions() {
+		label := string(v.Version)
+		if v.Default {
+			label += "*"
+		}
+		labels = append(labels, label)
+	}
+	return strings.Join(labels, ", ")
+}
+
+func runFmt(configPath string) error {
+	rewritten, err := config.FormatFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", configPath, err)
+	}
+
+	if len(rewritten) == 0 {
+		config.Info("%s is already formatted", configPath)
+		return nil
+	}
+
+	for _, nv := range rewritten {
+		fmt.Printf("  %s:%d: %s: %s -> \"%s\"\n", configPath, nv.Line, nv.Tool, nv.Text, nv.Text)
+	}
+	config.Info("Quoted %d numeric version(s) in %s", len(rewritten), configPath)
+	return nil
+}
+
 func printHelp() {
 	fmt.Print(`mise-seq - Tool installer with hooks
 
 Usage:
-  mise-seq [global-flags] <command> [command-flags] [args]
+  mise-seq [global-flags] <command> [command-flags] [tools...]
 
 Commands:
   install    Install all tools from config (default)
   upgrade    Upgrade installed tools
   list       List installed tools
   status     Show status of configured tools
+  plan       Show the actions install would take (-o <file> saves them)
+  apply      Execute a saved plan (refuses if the machine changed)
+  fmt        Quote numeric versions in the config file
+  logs       Show the step logs of the last run (logs <tool> for full output)
+  history    List recorded runs (history <run-id> for one run)
+  rollback   Re-activate the tool versions from before a run (rollback <run-id>)
 
 Global Flags:
   -c <file>     Config file (default: tools.yaml)
@@ -308,11 +1141,43 @@ Global Flags:
   --postinstall-on-update  Run postinstall on update
   -v            Verbose output
   --version     Show version
+  --output <f>  Output format: table (default), json or yaml
+  --log-format <f>  Log format on stderr: text (default) or json
+  --log-file <path> Also write debug-level JSON logs to this file
+  --log-dir <dir>   Step logs directory (default: $XDG_CACHE_HOME/tools/logs)
+  --log-retention <n|age>  Step logs to keep (default: 10 runs)
+  --ci          CI log mode (default when CI, GITHUB_ACTIONS or GITLAB_CI
+                is set; --ci=false to disable)
+  --report <format=path>  Also write a report file: json, yaml, junit or
+                markdown (repeatable; install, upgrade, apply, list, status)
+  --metrics-file <path>   Write Prometheus textfile-collector metrics
+                (install, upgrade, status)
+  --trace <format=path>   Export a timing trace: chrome or otlp; otlp may
+                also be sent to a collector (otlp=http://localhost:4318)
+
+Run Flags (install, upgrade, plan, list, status):
+  --keep-going       Continue with independent tools after a failure
+  --resume           Resume an interrupted install, skipping completed tools
+                     and hooks (install)
+  --atomic           Restore the mise global config and active versions if a
+                     required tool or hook fails (install, upgrade)
+  --tags <a,b>       Only tools with these tags or in these groups
+  --skip-tags <a,b>  Skip tools with these tags or in these groups
+  [tools...]         Only these tools (install, upgrade, status)
+  Dependencies of selected tools are always included.
 
 Examples:
   mise-seq install -c tools.yaml
+  mise-seq install --tags core
+  mise-seq install jq yq
   mise-seq upgrade
   mise-seq list
   mise-seq status
+  mise-seq plan -o plan.json
+  mise-seq apply plan.json
+  mise-seq -c tools.yaml fmt
+  mise-seq logs jq
+  mise-seq history
+  mise-seq rollback 20261018T120000Z-a1b2c3
 `)
 }
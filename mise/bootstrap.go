@@ -1,6 +1,7 @@
 package mise
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/proc"
 )
 
 // Bootstrapper handles bootstrapping (installing mise and cue if needed)
@@ -95,10 +97,11 @@ func (b *Bootstrapper) InstallCue(ctx context.Context) error {
 	}
 
 	// Install cue using mise
-	cmd := exec.CommandContext(ctx, "mise", "use", "-g", toolSpec)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to install cue: %w\noutput: %s", err, string(output))
+	cmd := proc.Command(ctx, "mise", "use", "-g", toolSpec)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := runCommand(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to install cue: %w\noutput: %s", err, output.String())
 	}
 
 	// Find cue again
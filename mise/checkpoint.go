@@ -0,0 +1,151 @@
+package mise
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+)
+
+// checkpointFile is the name of the install checkpoint in the state directory
+const checkpointFile = ".checkpoint.json"
+
+// Checkpoint records the progress of an install, so that an interrupted or
+// failed run can resume where it stopped. It belongs to one config: a
+// checkpoint is only resumed by a run with the same config hash.
+type Checkpoint struct {
+	ConfigHash string    `json:"config_hash"`
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Tools      []string  `json:"tools,omitempty"` // completed tools, in order
+	Hooks      []string  `json:"hooks,omitempty"` // completed hooks, as <state key>/<type>/<index>
+
+	path string
+}
+
+// CheckpointPath returns the checkpoint file in stateDir
+func CheckpointPath(stateDir string) string {
+	return filepath.Join(stateDir, checkpointFile)
+}
+
+// LoadCheckpoint reads the checkpoint in stateDir; nil if there is none
+func LoadCheckpoint(stateDir string) (*Checkpoint, error) {
+	path := CheckpointPath(stateDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	cp.path = path
+	return &cp, nil
+}
+
+// startCheckpoint starts the checkpoint of an install keyed by the config
+// hash given to WithCheckpoint; without one, nothing is checkpointed. With
+// resume it continues the checkpoint in the state directory, refusing one
+// made for a different config; otherwise, or without a checkpoint, it starts
+// afresh.
+func (i *Installer) startCheckpoint() (*Checkpoint, error) {
+	if i.configHash == "" {
+		return nil, nil
+	}
+	stateDir := i.opts.stateManager(false).StateDir
+
+	if i.resume {
+		cp, err := LoadCheckpoint(stateDir)
+		if err != nil {
+			return nil, err
+		}
+		if cp != nil {
+			if cp.ConfigHash != i.configHash {
+				return nil, fmt.Errorf("checkpoint %s is from a different config (started %s); run install without --resume",
+					cp.path, cp.StartedAt.Local().Format("2006-01-02 15:04:05"))
+			}
+			config.Info("Resuming install started %s: %d tool(s) already done",
+				cp.StartedAt.Local().Format("2006-01-02 15:04:05"), len(cp.Tools))
+			return cp, nil
+		}
+		config.Info("No checkpoint to resume; installing all tools")
+	}
+
+	now := time.Now().UTC()
+	cp := &Checkpoint{ConfigHash: i.configHash, StartedAt: now, UpdatedAt: now, path: CheckpointPath(stateDir)}
+	return cp, cp.save()
+}
+
+// toolDone reports whether tool was completed
+func (cp *Checkpoint) toolDone(tool string) bool {
+	return cp != nil && contains(cp.Tools, tool)
+}
+
+// hookDone reports whether the hook with key was completed
+func (cp *Checkpoint) hookDone(key string) bool {
+	return cp != nil && contains(cp.Hooks, key)
+}
+
+// completeTool records a completed tool
+func (cp *Checkpoint) completeTool(tool string) error {
+	if cp == nil || contains(cp.Tools, tool) {
+		return nil
+	}
+	cp.Tools = append(cp.Tools, tool)
+	return cp.save()
+}
+
+// completeHook records a completed hook
+func (cp *Checkpoint) completeHook(key string) error {
+	if cp == nil || contains(cp.Hooks, key) {
+		return nil
+	}
+	cp.Hooks = append(cp.Hooks, key)
+	return cp.save()
+}
+
+// save writes the checkpoint through a temporary file, so an interrupted
+// write never leaves a truncated checkpoint
+func (cp *Checkpoint) save() error {
+	cp.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	if err := writeFileAtomic(cp.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the checkpoint of a finished install
+func (cp *Checkpoint) remove() error {
+	if cp == nil {
+		return nil
+	}
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// hookKey identifies a hook in a checkpoint
+func hookKey(stateKey string, hookType string, index int) string {
+	return fmt.Sprintf("%s/%s/%d", stateKey, hookType, index)
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
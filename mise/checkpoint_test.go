@@ -0,0 +1,90 @@
+package mise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mise-seq/config-loader/config"
+)
+
+func TestInstaller_Resume(t *testing.T) {
+	logFile := fakeMise(t)
+	dir := filepath.Dir(logFile)
+	stateDir := filepath.Join(dir, "state")
+	counter := filepath.Join(dir, "counter")
+	failFlag := filepath.Join(dir, "fail")
+	if err := os.WriteFile(failFlag, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"jq", "rg"},
+		Tools: map[string]config.Tool{
+			"jq": {},
+			"rg": {Postinstall: []config.Hook{
+				{Run: "echo first >> " + counter},
+				{Run: "test ! -e " + failFlag},
+			}},
+		},
+	}
+	opts := []InstallerOption{WithStateDir(stateDir), WithCheckpoint("hash-1", true)}
+
+	// The second hook of rg fails: jq and the first hook are checkpointed
+	if _, err := NewInstaller(NewClient(), opts...).Install(context.Background(), cfg); err == nil {
+		t.Fatal("Expected the failing hook to fail the install")
+	}
+	cp, err := LoadCheckpoint(stateDir)
+	if err != nil || cp == nil {
+		t.Fatalf("LoadCheckpoint() = %v, %v", cp, err)
+	}
+	if cp.ConfigHash != "hash-1" || strings.Join(cp.Tools, ",") != "jq" || strings.Join(cp.Hooks, ",") != "rg/postinstall/0" {
+		t.Errorf("unexpected checkpoint: %+v", cp)
+	}
+
+	// A checkpoint of another config is refused
+	other := []InstallerOption{WithStateDir(stateDir), WithCheckpoint("hash-2", true)}
+	if _, err := NewInstaller(NewClient(), other...).Install(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "different config") {
+		t.Errorf("Expected a stale checkpoint to be refused, got %v", err)
+	}
+
+	if err := os.Remove(failFlag); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(logFile); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := NewInstaller(NewClient(), opts...).Install(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Resumed install failed: %v", err)
+	}
+	if summary.Results[0].Status != ToolSkipped || summary.Results[1].Status != ToolSucceeded {
+		t.Errorf("unexpected results: %+v %+v", summary.Results[0], summary.Results[1])
+	}
+	if calls := strings.Join(miseCalls(t, logFile), "\n"); strings.Contains(calls, "install jq") {
+		t.Error("Expected the resumed install to skip jq")
+	}
+	if data, _ := os.ReadFile(counter); strings.Count(string(data), "first") != 1 {
+		t.Errorf("Expected the completed hook to run once, got %q", data)
+	}
+
+	// A successful install removes the checkpoint
+	if cp, err := LoadCheckpoint(stateDir); cp != nil || err != nil {
+		t.Errorf("Expected no checkpoint after success, got %+v, %v", cp, err)
+	}
+}
+
+func TestInstaller_NoCheckpointWithoutConfigHash(t *testing.T) {
+	logFile := fakeMise(t)
+	stateDir := filepath.Join(filepath.Dir(logFile), "state")
+
+	cfg := &config.Config{Tools: map[string]config.Tool{"jq": {Postinstall: []config.Hook{{Run: "false"}}}}}
+	if _, err := NewInstaller(NewClient(), WithStateDir(stateDir)).Install(context.Background(), cfg); err == nil {
+		t.Fatal("Expected the failing hook to fail the install")
+	}
+	if cp, err := LoadCheckpoint(stateDir); cp != nil || err != nil {
+		t.Errorf("Expected no checkpoint without a config hash, got %+v, %v", cp, err)
+	}
+}
@@ -0,0 +1,153 @@
+package mise
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+)
+
+// CIProvider is a CI system whose log syntax the CI observer uses
+type CIProvider string
+
+const (
+	CINone    CIProvider = ""
+	CIGitHub  CIProvider = "github"  // GitHub Actions workflow commands (also Gitea/Forgejo)
+	CIGitLab  CIProvider = "gitlab"  // GitLab CI collapsible sections
+	CIGeneric CIProvider = "generic" // CI is set, but the system is unknown
+)
+
+// DetectCI detects the CI system from the environment
+func DetectCI(getenv func(string) string) CIProvider {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return CIGitHub
+	case getenv("GITLAB_CI") == "true":
+		return CIGitLab
+	}
+	switch strings.ToLower(getenv("CI")) {
+	case "", "0", "false", "no":
+		return CINone
+	}
+	return CIGeneric
+}
+
+// CIObserver prints installer progress for CI logs. Each tool's install and
+// hooks are wrapped in a collapsible group, failures become error or warning
+// annotations, and secret values are masked.
+//
+// On GitHub Actions it uses workflow commands (::group::, ::error::,
+// ::add-mask::). On GitLab it uses section_start/section_end markers, and
+// masks secrets by replacing them in the output. Other CI systems only get
+// the masking.
+type CIObserver struct {
+	text     *TextObserver
+	provider CIProvider
+	section  string // open GitLab section
+	sections int
+}
+
+// NewCIObserver creates a CI observer writing to out and errOut. Secret values
+// from the environment are masked from the start.
+func NewCIObserver(provider CIProvider, out, errOut io.Writer, verbose bool) *CIObserver {
+	secrets := config.SecretValues(os.Environ())
+	if provider == CIGitHub {
+		// The runner hides masked values in every later log line
+		for _, s := range secrets {
+			fmt.Fprintf(out, "::add-mask::%s\n", escapeWorkflowData(s))
+		}
+	} else if len(secrets) > 0 {
+		out = config.NewMaskingWriter(out, secrets)
+		errOut = config.NewMaskingWriter(errOut, secrets)
+	}
+	return &CIObserver{
+		text:     NewTextObserver(out, errOut, verbose),
+		provider: provider,
+	}
+}
+
+// OnEvent prints the event
+func (o *CIObserver) OnEvent(e Event) {
+	switch e.Type {
+	case EventToolStarted:
+		title := "Installing " + e.Tool
+		if e.Action == "upgrade" {
+			title = "Upgrading " + e.Tool
+		}
+		o.startGroup(title, e.Tool)
+		if o.provider == CIGitLab || o.provider == CIGeneric {
+			o.text.OnEvent(e)
+		}
+	case EventToolSucceeded:
+		o.endGroup()
+	case EventToolFailed:
+		o.endGroup()
+		o.annotate(e)
+	default:
+		o.text.OnEvent(e)
+	}
+}
+
+// startGroup opens a collapsible group
+func (o *CIObserver) startGroup(title, tool string) {
+	out := o.text.Out
+	switch o.provider {
+	case CIGitHub:
+		fmt.Fprintf(out, "::group::%s\n", escapeWorkflowData(title))
+	case CIGitLab:
+		o.sections++
+		o.section = fmt.Sprintf("mise_seq_%d_%s", o.sections, sectionName.ReplaceAllString(tool, "_"))
+		fmt.Fprintf(out, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", time.Now().Unix(), o.section, title)
+	}
+}
+
+// endGroup closes the open group, if any
+func (o *CIObserver) endGroup() {
+	out := o.text.Out
+	switch o.provider {
+	case CIGitHub:
+		fmt.Fprintln(out, "::endgroup::")
+	case CIGitLab:
+		if o.section != "" {
+			fmt.Fprintf(out, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), o.section)
+			o.section = ""
+		}
+	}
+}
+
+// annotate reports a failed tool outside its group, so it stays visible
+func (o *CIObserver) annotate(e Event) {
+	if o.provider != CIGitHub {
+		if e.Optional || e.Continuing {
+			o.text.OnEvent(e)
+		} else {
+			fmt.Fprintf(o.text.Out, "[ERROR] %s failed: %v\n", e.Tool, e.Err)
+		}
+		return
+	}
+
+	level := "error"
+	title := fmt.Sprintf("%s failed", e.Tool)
+	if e.Optional {
+		level = "warning"
+		title = fmt.Sprintf("Optional tool %s failed", e.Tool)
+	}
+	fmt.Fprintf(o.text.Out, "::%s title=%s::%s\n", level, escapeWorkflowProperty(title), escapeWorkflowData(fmt.Sprint(e.Err)))
+}
+
+// sectionName matches characters not allowed in GitLab section names
+var sectionName = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// escapeWorkflowData escapes the message of a GitHub workflow command
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeWorkflowProperty escapes a property value of a GitHub workflow command
+func escapeWorkflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
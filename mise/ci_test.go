@@ -0,0 +1,89 @@
+package mise
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mise-seq/config-loader/hooks"
+)
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected CIProvider
+	}{
+		{"none", nil, CINone},
+		{"ci false", map[string]string{"CI": "false"}, CINone},
+		{"generic", map[string]string{"CI": "true"}, CIGeneric},
+		{"github", map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, CIGitHub},
+		{"gitlab", map[string]string{"CI": "true", "GITLAB_CI": "true"}, CIGitLab},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := DetectCI(getenv); got != tt.expected {
+				t.Errorf("DetectCI() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// ciEvents is a run with one succeeding tool with a hook and one failing tool
+func ciEvents(o Observer) {
+	o.OnEvent(Event{Type: EventToolStarted, Tool: "jq", Action: "install"})
+	o.OnEvent(Event{Type: EventHookFinished, Tool: "jq", HookType: hooks.HookTypePostinstall,
+		HookResult: &hooks.HookResult{Stdout: "using glpat-0123456789\n"}})
+	o.OnEvent(Event{Type: EventToolSucceeded, Tool: "jq", Action: "install"})
+	o.OnEvent(Event{Type: EventToolStarted, Tool: "rg", Action: "install", Optional: true})
+	o.OnEvent(Event{Type: EventToolFailed, Tool: "rg", Action: "install", Optional: true, Continuing: true,
+		Err: errors.New("install failed: 50% done\nexit 1")})
+}
+
+func TestCIObserver_GitHub(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "glpat-0123456789")
+
+	var out bytes.Buffer
+	ciEvents(NewCIObserver(CIGitHub, &out, &out, false))
+
+	// Other tokens of the environment may be masked too
+	if !strings.Contains(out.String(), "::add-mask::glpat-0123456789\n") {
+		t.Errorf("Expected the token to be masked:\n%s", out.String())
+	}
+	expected := "::group::Installing jq\n" +
+		"using glpat-0123456789\n" +
+		"::endgroup::\n" +
+		"::group::Installing rg\n" +
+		"::endgroup::\n" +
+		"::warning title=Optional tool rg failed::install failed: 50%25 done%0Aexit 1\n"
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestCIObserver_GitLab(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "glpat-0123456789")
+
+	var out bytes.Buffer
+	ciEvents(NewCIObserver(CIGitLab, &out, &out, false))
+	text := out.String()
+
+	for _, want := range []string{
+		"section_start:",
+		":mise_seq_1_jq[collapsed=true]\r\x1b[0KInstalling jq\n",
+		"section_end:",
+		":mise_seq_2_rg\r\x1b[0K\n",
+		"using [MASKED]\n",
+		"[WARN] Optional tool rg failed, continuing",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in output:\n%q", want, text)
+		}
+	}
+	if strings.Contains(text, "glpat-0123456789") || strings.Contains(text, "::") {
+		t.Errorf("Unexpected token or workflow command in output:\n%q", text)
+	}
+}
@@ -0,0 +1,61 @@
+package mise
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorClass is a coarse category of a tool failure, used by reports to
+// group failures without parsing error messages
+type ErrorClass string
+
+const (
+	ErrorNotInRegistry     ErrorClass = "not_in_registry"    // mise does not know the tool
+	ErrorVersionResolution ErrorClass = "version_resolution" // no version matches the constraint
+	ErrorInstall           ErrorClass = "install_failed"     // mise install failed
+	ErrorUpgrade           ErrorClass = "upgrade_failed"     // mise upgrade failed
+	ErrorActivation        ErrorClass = "activation_failed"  // mise use -g failed
+	ErrorHook              ErrorClass = "hook_failed"        // a preinstall or postinstall hook failed
+	ErrorTimeout           ErrorClass = "timeout"            // the context deadline passed
+	ErrorCanceled          ErrorClass = "canceled"           // the run was canceled
+	ErrorOther             ErrorClass = "error"              // anything else
+)
+
+// classError tags an error with the step of a tool run that failed.
+// Its message is the wrapped error's.
+type classError struct {
+	class ErrorClass
+	err   error
+}
+
+func (e *classError) Error() string { return e.err.Error() }
+func (e *classError) Unwrap() error { return e.err }
+
+// classify tags err with class; nil stays nil
+func classify(class ErrorClass, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classError{class: class, err: err}
+}
+
+// ClassifyError returns the class of a tool failure returned by an Installer.
+// Timeouts, cancellation and unknown tools take precedence over the step that
+// failed. It returns "" for a nil error.
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorCanceled
+	case errors.Is(err, ErrNotInRegistry):
+		return ErrorNotInRegistry
+	}
+	var ce *classError
+	if errors.As(err, &ce) {
+		return ce.class
+	}
+	return ErrorOther
+}
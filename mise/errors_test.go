@@ -0,0 +1,37 @@
+package mise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorClass
+	}{
+		{"nil", nil, ""},
+		{"plain", errors.New("boom"), ErrorOther},
+		{"hook", classify(ErrorHook, errors.New("exit 1")), ErrorHook},
+		{"wrapped step", fmt.Errorf("jq: %w", classify(ErrorInstall, errors.New("exit 1"))), ErrorInstall},
+		{"not in registry", classify(ErrorInstall, fmt.Errorf("tool nope %w", ErrNotInRegistry)), ErrorNotInRegistry},
+		{"timeout", classify(ErrorHook, fmt.Errorf("hook: %w", context.DeadlineExceeded)), ErrorTimeout},
+		{"canceled", context.Canceled, ErrorCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.expected {
+				t.Errorf("ClassifyError() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	// Classifying keeps the message
+	if err := classify(ErrorHook, errors.New("exit 1")); err.Error() != "exit 1" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
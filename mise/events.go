@@ -0,0 +1,126 @@
+package mise
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/hooks"
+)
+
+// EventType identifies an installer event
+type EventType string
+
+const (
+	EventToolStarted     EventType = "tool_started"     // a tool's install or upgrade begins
+	EventToolSucceeded   EventType = "tool_succeeded"   // a tool finished without errors
+	EventToolSkipped     EventType = "tool_skipped"     // a tool was excluded or not attempted
+	EventToolFailed      EventType = "tool_failed"      // a tool failed
+	EventCommandFinished EventType = "command_finished" // a mise command that changes the machine returned
+	EventHookStarted     EventType = "hook_started"     // a hook is about to run
+	EventHookFinished    EventType = "hook_finished"    // a hook ran, or was skipped by its state marker
+)
+
+// Event is one step of an installer run. Fields not relevant to the event
+// type are left empty.
+type Event struct {
+	Type     EventType
+	Time     time.Time
+	Tool     string
+	Action   string // install or upgrade
+	Version  string // resolved version, when known
+	Optional bool   // the tool is optional
+
+	// Command is the mise command line of EventCommandFinished, and
+	// Result its captured output
+	Command []string
+	Result  *Result
+
+	// Env lists the variables set for a command or hook on top of the
+	// inherited environment, as KEY=value
+	Env []string
+
+	// Hook fields of EventHookStarted and EventHookFinished
+	HookType    hooks.HookType
+	Script      string
+	Description string
+	HookResult  *hooks.HookResult
+
+	Duration time.Duration
+	Reason   string // why a tool was skipped or failed
+	Err      error
+
+	// Continuing is set on EventToolFailed when the run goes on
+	Continuing bool
+}
+
+// Observer receives installer events. Events are delivered synchronously,
+// in order, from the goroutine running the installer.
+type Observer interface {
+	OnEvent(Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface
+type ObserverFunc func(Event)
+
+// OnEvent calls f(e)
+func (f ObserverFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// ChannelObserver returns an observer sending events to ch. The installer
+// blocks while ch is full, so the receiver must keep draining it.
+func ChannelObserver(ch chan<- Event) Observer {
+	return ObserverFunc(func(e Event) { ch <- e })
+}
+
+// TextObserver prints installer progress as plain text, as the CLI does
+type TextObserver struct {
+	Out     io.Writer
+	Err     io.Writer
+	Verbose bool
+}
+
+// NewTextObserver creates a text observer writing to out and errOut
+func NewTextObserver(out, errOut io.Writer, verbose bool) *TextObserver {
+	return &TextObserver{Out: out, Err: errOut, Verbose: verbose}
+}
+
+// OnEvent prints the event
+func (o *TextObserver) OnEvent(e Event) {
+	switch e.Type {
+	case EventToolStarted:
+		if e.Action == "upgrade" {
+			fmt.Fprintf(o.Out, "Upgrading %s\n", e.Tool)
+		} else {
+			fmt.Fprintf(o.Out, "Installing %s\n", e.Tool)
+		}
+	case EventToolSkipped:
+		fmt.Fprintf(o.Out, "Skipping %s: %s\n", e.Tool, e.Reason)
+	case EventToolFailed:
+		switch {
+		case e.Optional:
+			fmt.Fprintf(o.Out, "[WARN] Optional tool %s failed, continuing: %v\n", e.Tool, e.Err)
+		case e.Continuing:
+			fmt.Fprintf(o.Out, "[ERROR] %s failed, continuing: %v\n", e.Tool, e.Err)
+		}
+	case EventCommandFinished:
+		if o.Verbose {
+			fmt.Fprintf(o.Out, "  $ mise %s (%s)\n", strings.Join(e.Command, " "), e.Duration.Round(time.Millisecond))
+		}
+	case EventHookStarted:
+		if o.Verbose {
+			fmt.Fprintf(o.Out, "  Running %s hook for %s\n", e.HookType, e.Tool)
+		}
+	case EventHookFinished:
+		if r := e.HookResult; r != nil {
+			if r.Stdout != "" {
+				fmt.Fprint(o.Out, r.Stdout)
+			}
+			if r.Stderr != "" {
+				fmt.Fprint(o.Err, r.Stderr)
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package mise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/proc"
+)
+
+// fakeMise puts a mise stub on PATH that logs its arguments and fails to
+// install the given tools. mise ls --json prints ls.json next to the log
+// file, or {} if it does not exist. It returns the path of the log file.
+func fakeMise(t *testing.T, failing ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+
+	var cases strings.Builder
+	for _, tool := range failing {
+		cases.WriteString("  \"install " + tool + "@\"*) echo 'install failed' >&2; exit 1 ;;\n")
+	}
+
+	script := "#!/bin/sh\n" +
+		"echo \"$*\" >> " + logFile + "\n" +
+		"case \"$*\" in\n" +
+		"  \"ls --json\") cat " + filepath.Join(dir, "ls.json") + " 2>/dev/null || echo '{}' ;;\n" +
+		"  \"outdated --json\") cat " + filepath.Join(dir, "outdated.json") + " 2>/dev/null || echo '{}' ;;\n" +
+		cases.String() +
+		"esac\n" +
+		"exit 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "mise"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write mise stub: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("MISE_DATA_DIR", filepath.Join(dir, "data"))
+	t.Setenv("STATE_DIR", filepath.Join(dir, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	return logFile
+}
+
+// miseCalls returns the logged mise invocations
+func miseCalls(t *testing.T, logFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read mise log: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestInstallAllWithHooks_Optional(t *testing.T) {
+	logFile := fakeMise(t, "broken")
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"broken", "plugin", "jq"},
+		Tools: map[string]config.Tool{
+			"broken": {Optional: true},
+			"plugin": {Depends: []string{"broken"}},
+			"jq":     {},
+		},
+	}
+
+	if err := NewClient().InstallAllWithHooks(context.Background(), cfg, false); err != nil {
+		t.Fatalf("Expected optional failure to be soft, got: %v", err)
+	}
+
+	calls := strings.Join(miseCalls(t, logFile), "\n")
+	if strings.Contains(calls, "install plugin") {
+		t.Error("Expected dependent of failed optional tool to be skipped")
+	}
+	if !strings.Contains(calls, "install jq") {
+		t.Error("Expected jq to be installed after the optional failure")
+	}
+}
+
+func TestInstallAllWithHooks_RequiredFailure(t *testing.T) {
+	logFile := fakeMise(t, "broken")
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"broken", "jq"},
+		Tools: map[string]config.Tool{
+			"broken": {},
+			"jq":     {},
+		},
+	}
+
+	if err := NewClient().InstallAllWithHooks(context.Background(), cfg, false); err == nil {
+		t.Fatal("Expected failure of a required tool to stop the run")
+	}
+	if calls := strings.Join(miseCalls(t, logFile), "\n"); strings.Contains(calls, "install jq") {
+		t.Error("Expected jq not to be installed after a required failure")
+	}
+}
+
+func TestInstallAllWithHooks_Disabled(t *testing.T) {
+	logFile := fakeMise(t)
+
+	disabled := false
+	cfg := &config.Config{
+		Tools: map[string]config.Tool{
+			"jq": {Enabled: &disabled},
+			"yq": {},
+		},
+	}
+
+	if err := NewClient().InstallAllWithHooks(context.Background(), cfg, false); err != nil {
+		t.Fatalf("InstallAllWithHooks failed: %v", err)
+	}
+	if calls := strings.Join(miseCalls(t, logFile), "\n"); strings.Contains(calls, "install jq") {
+		t.Error("Expected disabled tool not to be installed")
+	}
+}
+
+func TestInstallAllWithOptions_KeepGoing(t *testing.T) {
+	logFile := fakeMise(t, "broken")
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"broken", "plugin", "jq"},
+		Tools: map[string]config.Tool{
+			"broken": {},
+			"plugin": {Depends: []string{"broken"}},
+			"jq":     {},
+		},
+	}
+
+	summary, err := NewClient().InstallAllWithOptions(context.Background(), cfg, InstallOptions{KeepGoing: true})
+	if err == nil {
+		t.Fatal("Expected an error for the failed tool")
+	}
+	if summary.ExitCode() != ExitFailed {
+		t.Errorf("Expected exit code %d, got %d", ExitFailed, summary.ExitCode())
+	}
+
+	expected := map[string]ToolStatus{"broken": ToolFailed, "plugin": ToolSkipped, "jq": ToolSucceeded}
+	if len(summary.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), summary.Results)
+	}
+	for _, r := range summary.Results {
+		if r.Status != expected[r.Tool] {
+			t.Errorf("%s: expected %s, got %s (%s)", r.Tool, expected[r.Tool], r.Status, r.Reason)
+		}
+	}
+
+	if calls := strings.Join(miseCalls(t, logFile), "\n"); !strings.Contains(calls, "install jq") {
+		t.Error("Expected jq to be installed after the failure")
+	}
+}
+
+func TestInstallAllWithOptions_FailFast(t *testing.T) {
+	fakeMise(t, "broken")
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"broken", "jq"},
+		Tools: map[string]config.Tool{
+			"broken": {},
+			"jq":     {},
+		},
+	}
+
+	summary, err := NewClient().InstallAllWithOptions(context.Background(), cfg, InstallOptions{})
+	if err == nil {
+		t.Fatal("Expected an error for the failed tool")
+	}
+	if len(summary.Results) != 2 || summary.Results[1].Status != ToolSkipped {
+		t.Errorf("Expected jq to be skipped after the failure, got %+v", summary.Results)
+	}
+}
+
+func TestInstaller_Interrupted(t *testing.T) {
+	fakeMise(t)
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"jq", "yq"},
+		Tools: map[string]config.Tool{
+			"jq": {Postinstall: []config.Hook{{Run: "sleep 5"}}},
+			"yq": {},
+		},
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(200*time.Millisecond, func() { cancel(&proc.Interrupt{Signal: os.Interrupt}) })
+
+	summary, err := NewInstaller(NewClient(), WithStateDir(t.TempDir())).Install(ctx, cfg)
+	if err == nil || !strings.Contains(err.Error(), "interrupted by SIGINT") {
+		t.Fatalf("Expected an interrupt error, got %v", err)
+	}
+	if summary.Interrupted != "SIGINT" || summary.ExitCode() != ExitInterrupted {
+		t.Errorf("Expected an interrupted summary, got %q exit %d", summary.Interrupted, summary.ExitCode())
+	}
+	jq, yq := summary.Results[0], summary.Results[1]
+	if jq.Status != ToolFailed || jq.ErrorClass != ErrorCanceled {
+		t.Errorf("jq = %+v, expected canceled", jq)
+	}
+	if yq.Status != ToolSkipped || !strings.Contains(yq.Reason, "interrupted") {
+		t.Errorf("yq = %+v, expected skipped after the interrupt", yq)
+	}
+}
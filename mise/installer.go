@@ -0,0 +1,645 @@
+package mise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/proc"
+	"github.com/mise-seq/config-loader/tracing"
+)
+
+// InstallOptions controls how an Installer runs
+type InstallOptions struct {
+	// KeepGoing continues with tools that do not depend on a failed tool
+	// instead of stopping at the first failure
+	KeepGoing bool `json:"keep_going,omitempty"`
+
+	// RunPostinstallOnUpdate runs postinstall hooks for upgraded tools
+	RunPostinstallOnUpdate bool `json:"run_postinstall_on_update,omitempty"`
+
+	// StateDir overrides the hook state directory
+	StateDir string `json:"state_dir,omitempty"`
+
+	// ForceHooks runs hooks even if their state marker matches
+	ForceHooks bool `json:"force_hooks,omitempty"`
+}
+
+// hookRunner returns a hook runner using the state options
+func (o InstallOptions) hookRunner(runPostinstallOnUpdate bool) *hooks.Runner {
+	return hooks.NewRunnerWithOptions(false, o.StateDir, o.ForceHooks, runPostinstallOnUpdate)
+}
+
+// stateManager returns the hook state manager matching hookRunner
+func (o InstallOptions) stateManager(runPostinstallOnUpdate bool) *hooks.StateManager {
+	stateMgr := hooks.NewStateManager()
+	if o.StateDir != "" {
+		stateMgr.StateDir = o.StateDir
+	}
+	stateMgr.ForceHooks = o.ForceHooks
+	stateMgr.RunPostinstallOnUpdate = runPostinstallOnUpdate
+	return stateMgr
+}
+
+// Installer installs and upgrades the tools of a config, reporting progress
+// to its observers
+type Installer struct {
+	client    *Client
+	opts      InstallOptions
+	observers []Observer
+	masker    secretMasker
+
+	// Checkpoints of Install
+	configHash string
+	resume     bool
+	checkpoint *Checkpoint // of the running Install
+}
+
+// InstallerOption configures an Installer
+type InstallerOption func(*Installer)
+
+// WithOptions sets all install options at once
+func WithOptions(opts InstallOptions) InstallerOption {
+	return func(i *Installer) { i.opts = opts }
+}
+
+// WithKeepGoing continues with independent tools after a failure
+func WithKeepGoing(keepGoing bool) InstallerOption {
+	return func(i *Installer) { i.opts.KeepGoing = keepGoing }
+}
+
+// WithPostinstallOnUpdate runs postinstall hooks for upgraded tools
+func WithPostinstallOnUpdate(run bool) InstallerOption {
+	return func(i *Installer) { i.opts.RunPostinstallOnUpdate = run }
+}
+
+// WithStateDir overrides the hook state directory
+func WithStateDir(dir string) InstallerOption {
+	return func(i *Installer) { i.opts.StateDir = dir }
+}
+
+// WithForceHooks runs hooks even if their state marker matches
+func WithForceHooks(force bool) InstallerOption {
+	return func(i *Installer) { i.opts.ForceHooks = force }
+}
+
+// WithCheckpoint makes Install checkpoint its progress, keyed by configHash:
+// the config.HashFile of the config file, as plans and history use. With
+// resume, Install skips the tools and hooks a checkpoint of the same config
+// records as done.
+func WithCheckpoint(configHash string, resume bool) InstallerOption {
+	return func(i *Installer) {
+		i.configHash = configHash
+		i.resume = resume
+	}
+}
+
+// WithObserver adds an observer receiving the installer's events
+func WithObserver(o Observer) InstallerOption {
+	return func(i *Installer) { i.observers = append(i.observers, o) }
+}
+
+// NewInstaller creates an installer running mise through client.
+// Without observers it runs silently.
+func NewInstaller(client *Client, opts ...InstallerOption) *Installer {
+	i := &Installer{client: client, masker: newSecretMasker()}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Options returns the installer's options
+func (i *Installer) Options() InstallOptions {
+	return i.opts
+}
+
+// emit sends an event to every observer, with secrets masked
+func (i *Installer) emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e = i.masker.event(e)
+	for _, o := range i.observers {
+		o.OnEvent(e)
+	}
+}
+
+// commandFinished emits EventCommandFinished for a mise command and logs it
+// with the attributes of ctx
+func (i *Installer) commandFinished(ctx context.Context, tool string, args, env []string, start time.Time, result *Result, err error) {
+	if err == nil && result != nil {
+		err = result.Error
+	}
+	duration := time.Since(start)
+	i.emit(Event{
+		Type:     EventCommandFinished,
+		Tool:     tool,
+		Command:  args,
+		Env:      env,
+		Result:   result,
+		Duration: duration,
+		Err:      err,
+	})
+
+	attrs := []any{"command", "mise " + strings.Join(args, " "), "duration_ms", duration.Milliseconds()}
+	if result != nil {
+		attrs = append(attrs, "exit_code", result.ExitCode)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	config.GetLogger().Slog().DebugContext(ctx, "mise command finished", attrs...)
+}
+
+// Install installs all tools from config with hooks, respecting tools_order
+// and dependencies, and returns a summary of the run. Tools already managed
+// by mise are upgraded. With WithCheckpoint, progress is checkpointed in the
+// state directory until the install succeeds.
+func (i *Installer) Install(ctx context.Context, cfg *config.Config) (*Summary, error) {
+	cp, err := i.startCheckpoint()
+	if err != nil {
+		return &Summary{}, err
+	}
+	i.checkpoint = cp
+	defer func() { i.checkpoint = nil }()
+
+	summary, err := i.runAll(ctx, cfg, func(ctx context.Context, name string, tool config.Tool, res *ToolResult) error {
+		return i.installOrUpgradeTool(ctx, cfg, name, tool, res)
+	})
+	if err == nil {
+		if rerr := cp.remove(); rerr != nil {
+			config.WarnContext(ctx, "%v", rerr)
+		}
+	}
+	return summary, err
+}
+
+// Upgrade upgrades all tools from config and returns a summary of the run
+func (i *Installer) Upgrade(ctx context.Context, cfg *config.Config) (*Summary, error) {
+	return i.runAll(ctx, cfg, func(ctx context.Context, name string, tool config.Tool, res *ToolResult) error {
+		res.Action = "upgrade"
+		i.emit(Event{Type: EventToolStarted, Tool: name, Action: "upgrade", Optional: tool.Optional})
+		return i.upgradeTool(ctx, cfg, name, tool, res)
+	})
+}
+
+// InstallTool installs one tool of cfg with its hooks.
+// Every entry of a versions list is installed; only the default is set globally.
+func (i *Installer) InstallTool(ctx context.Context, cfg *config.Config, toolName string) error {
+	ctx = config.WithLogAttrs(ctx, "tool", toolName)
+	return i.installWithHooks(ctx, cfg, toolName, &ToolResult{Tool: toolName, Action: "install"})
+}
+
+// runAll applies action to every tool in install order.
+// A failing optional tool is a warning. A failing required tool stops the run,
+// unless KeepGoing is set. Dependents of failed tools are skipped.
+func (i *Installer) runAll(ctx context.Context, cfg *config.Config, action func(ctx context.Context, name string, tool config.Tool, res *ToolResult) error) (*Summary, error) {
+	summary := &Summary{}
+
+	cfg, excluded := i.client.filterConfig(cfg)
+	for _, ex := range excluded {
+		i.emit(Event{Type: EventToolSkipped, Tool: ex.Tool, Reason: ex.Reason})
+		summary.add(&ToolResult{Tool: ex.Tool, Action: "-", Status: ToolSkipped, Reason: ex.Reason})
+	}
+
+	tools := config.GetTools(cfg)
+	installOrder, err := installOrder(cfg)
+	if err != nil {
+		return summary, err
+	}
+
+	// Tools that failed or were skipped; their dependents are skipped
+	unavailable := make(map[string]bool)
+	var abortErr error
+
+	// Install in determined order
+	for _, name := range installOrder {
+		tool, exists := tools[name]
+		if !exists {
+			continue
+		}
+
+		res := summary.add(&ToolResult{Tool: name, Action: "install", Optional: tool.Optional})
+
+		if interrupt := proc.Interrupted(ctx); interrupt != nil {
+			res.Status = ToolSkipped
+			res.Reason = "not attempted: " + interrupt.Error()
+			i.emit(Event{Type: EventToolSkipped, Tool: name, Optional: tool.Optional, Reason: res.Reason})
+			continue
+		}
+
+		if abortErr != nil {
+			res.Status = ToolSkipped
+			res.Reason = "not attempted after an earlier failure"
+			i.emit(Event{Type: EventToolSkipped, Tool: name, Optional: tool.Optional, Reason: res.Reason})
+			continue
+		}
+
+		if i.checkpoint.toolDone(name) {
+			res.Status = ToolSkipped
+			res.Reason = "done in the resumed run"
+			i.emit(Event{Type: EventToolSkipped, Tool: name, Optional: tool.Optional, Reason: res.Reason})
+			continue
+		}
+
+		if dep := unavailableDependency(tool, unavailable); dep != "" {
+			res.Status = ToolSkipped
+			res.Reason = fmt.Sprintf("depends on failed tool '%s'", dep)
+			i.emit(Event{Type: EventToolSkipped, Tool: name, Optional: tool.Optional, Reason: res.Reason})
+			unavailable[name] = true
+			continue
+		}
+
+		// Log records and spans of this tool carry its name
+		toolCtx := config.WithLogAttrs(ctx, "tool", name)
+		toolCtx, span := tracing.Start(toolCtx, "tool "+name, tracing.Attr{Key: "tool", Value: name})
+		start := time.Now()
+		err := action(toolCtx, name, tool, res)
+		res.Duration = time.Since(start)
+		if interrupt := proc.Interrupted(ctx); interrupt != nil && err != nil && !errors.Is(err, interrupt) {
+			// mise was stopped by the signal, not failing on its own
+			err = fmt.Errorf("%w: %w", interrupt, err)
+		}
+		// The error may quote hook or mise output; it reaches logs, spans,
+		// the summary, reports and the caller masked
+		err = i.masker.err(err)
+		logToolResult(toolCtx, res, err)
+		span.SetAttr("action", res.Action)
+		if err != nil {
+			span.SetAttr("error_class", string(ClassifyError(err)))
+		}
+		span.Finish(err)
+		if err == nil {
+			res.Status = ToolSucceeded
+			i.emit(Event{Type: EventToolSucceeded, Tool: name, Action: res.Action, Optional: tool.Optional, Duration: res.Duration})
+			if cerr := i.checkpoint.completeTool(name); cerr != nil {
+				config.WarnContext(toolCtx, "%v", cerr)
+			}
+			continue
+		}
+
+		res.Status = ToolFailed
+		res.Reason = err.Error()
+		res.ErrorClass = ClassifyError(err)
+		unavailable[name] = true
+		continuing := (tool.Optional || i.opts.KeepGoing) && proc.Interrupted(ctx) == nil
+		if !continuing {
+			abortErr = err
+		}
+		i.emit(Event{
+			Type:       EventToolFailed,
+			Tool:       name,
+			Action:     res.Action,
+			Optional:   tool.Optional,
+			Duration:   res.Duration,
+			Reason:     res.Reason,
+			Err:        err,
+			Continuing: continuing,
+		})
+	}
+
+	if interrupt := proc.Interrupted(ctx); interrupt != nil {
+		summary.Interrupted = interrupt.SignalName()
+		return summary, interrupt
+	}
+	if abortErr != nil {
+		return summary, abortErr
+	}
+	if failed := summary.RequiredFailures(); len(failed) > 0 {
+		names := make([]string, len(failed))
+		for n, r := range failed {
+			names[n] = r.Tool
+		}
+		return summary, fmt.Errorf("%d tool(s) failed: %s", len(failed), strings.Join(names, ", "))
+	}
+	return summary, nil
+}
+
+// installOrUpgradeTool upgrades a tool already managed by mise, or installs it
+func (i *Installer) installOrUpgradeTool(ctx context.Context, cfg *config.Config, name string, tool config.Tool, res *ToolResult) error {
+	// Check if already managed by mise
+	// Tools with a versions list always take the install flow, which
+	// installs any missing version and re-activates the default
+	if !tool.HasMultipleVersions() && i.client.IsManagedByMise(ctx, name) {
+		// Tool is already managed - run update flow
+		res.Action = "upgrade"
+		i.emit(Event{Type: EventToolStarted, Tool: name, Action: "upgrade", Optional: tool.Optional})
+		return i.upgradeTool(ctx, cfg, name, tool, res)
+	}
+
+	// Tool is not managed - run install flow
+	i.emit(Event{Type: EventToolStarted, Tool: name, Action: "install", Optional: tool.Optional})
+	return i.installWithHooks(ctx, cfg, name, res)
+}
+
+// installWithHooks installs every version of a tool with its hooks,
+// recording hook counts in res
+func (i *Installer) installWithHooks(ctx context.Context, cfg *config.Config, toolName string, res *ToolResult) error {
+	c := i.client
+	hookRunner := i.opts.hookRunner(false)
+	tool, exists := cfg.Tools[toolName]
+	if !exists {
+		return fmt.Errorf("tool %s not found in config", toolName)
+	}
+
+	env := config.MergeEnv(cfg.DefaultsEnv(), tool.Env)
+	for _, tv := range tool.InstallVersions() {
+		stateKey := hookStateKey(toolName, tool, tv.Version)
+
+		// Run preinstall hooks
+		if err := i.runToolHooks(ctx, hookRunner, toolName, stateKey, hooks.HookTypePreinstall, tool.Preinstall, env, res); err != nil {
+			return err
+		}
+		ctx := config.WithLogAttrs(ctx, "phase", "install")
+
+		// Resolve version constraints against mise ls-remote
+		version, err := c.ResolveVersion(ctx, toolName, string(tv.Version))
+		if err != nil {
+			return classify(ErrorVersionResolution, fmt.Errorf("failed to resolve version for %s: %w", toolName, err))
+		}
+
+		// Install tool
+		// Tool options (exe, matching, ...) are passed inline so backends such as
+		// ubi see them before the global config entry is written
+		toolSpec := fmt.Sprintf("%s@%s", toolName, version)
+		installSpec := ToolSpecWithOptions(toolName, version, tool.MiseOptions())
+		var result *Result
+		start := time.Now()
+		if tool.HasMultipleVersions() || config.IsConstraint(string(tv.Version)) {
+			// Other versions may already be installed (a versions list, or an
+			// active version outside the constraint), so check this one specifically
+			installed, err := c.IsVersionInstalled(ctx, toolName, version)
+			if err != nil {
+				return classify(ErrorInstall, fmt.Errorf("install failed for %s: %w", toolSpec, err))
+			}
+			if !installed {
+				result, err = c.InstallWithOutput(ctx, installSpec)
+				i.commandFinished(ctx, toolName, []string{"install", installSpec}, commandEnv(), start, result, err)
+			}
+		} else {
+			var installed bool
+			installed, result, err = c.InstallIfNotInstalled(ctx, installSpec)
+			if installed || err != nil {
+				i.commandFinished(ctx, toolName, []string{"install", installSpec}, commandEnv(), start, result, err)
+			}
+		}
+		if err != nil {
+			return classify(ErrorInstall, fmt.Errorf("install failed for %s: %w", toolName, err))
+		}
+		if result != nil && result.Error != nil {
+			return classify(ErrorInstall, fmt.Errorf("install error for %s: %w", toolSpec, result.Error))
+		}
+
+		// Set as global default (equivalent to mise use -g)
+		if tv.Default {
+			start := time.Now()
+			err := c.SetGlobalWithOptions(ctx, toolName, version, tool.MiseOptions())
+			i.commandFinished(ctx, toolName, []string{"use", "-g", ToolSpecWithOptions(toolName, version, tool.MiseOptions())}, commandEnv(), start, nil, err)
+			if err != nil {
+				return classify(ErrorActivation, fmt.Errorf("failed to set global default for %s: %w", toolName, err))
+			}
+		}
+
+		// Run postinstall hooks
+		if err := i.runToolHooks(ctx, hookRunner, toolName, stateKey, hooks.HookTypePostinstall, tool.Postinstall, env, res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upgradeTool upgrades a tool and runs its postinstall hooks if requested.
+// mise upgrade keeps to the version mise was given, not the configured
+// constraint, so a tool whose active version no longer satisfies its
+// constraint is installed at the resolved version and activated instead.
+func (i *Installer) upgradeTool(ctx context.Context, cfg *config.Config, name string, tool config.Tool, res *ToolResult) error {
+	if !tool.HasMultipleVersions() && config.IsConstraint(string(tool.Version)) {
+		inv, err := i.client.Inventory(ctx)
+		if err != nil {
+			return classify(ErrorUpgrade, fmt.Errorf("failed to upgrade %s: %w", name, err))
+		}
+		if active := inv.Active(name); outsideConstraint(string(tool.Version), active) {
+			config.InfoContext(ctx, "%s %s does not satisfy '%s'", name, active, tool.Version)
+			return i.installWithHooks(ctx, cfg, name, res)
+		}
+	}
+
+	start := time.Now()
+	upgradeCtx := config.WithLogAttrs(ctx, "phase", "upgrade")
+	result, err := i.client.UpgradeWithOutput(upgradeCtx, name)
+	i.commandFinished(upgradeCtx, name, []string{"upgrade", name}, getMiseEnv(), start, result, err)
+	if err == nil && result.Error != nil {
+		err = result.Error
+		if line := firstLine(result.Stderr); line != "" {
+			err = fmt.Errorf("%w: %s", err, line)
+		}
+	}
+	if err != nil {
+		return classify(ErrorUpgrade, fmt.Errorf("failed to upgrade %s: %w", name, err))
+	}
+
+	// Run postinstall hooks (update phase)
+	if i.opts.RunPostinstallOnUpdate {
+		hookRunner := i.opts.hookRunner(true)
+		if err := i.runToolHooks(ctx, hookRunner, name, name, hooks.HookTypePostinstall, tool.Postinstall, config.MergeEnv(cfg.DefaultsEnv(), tool.Env), res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runToolHooks runs a tool's hooks of one type, one event pair per hook.
+// Like hooks.Runner.RunHooks, every hook runs and the last error is returned.
+// env is the defaults and tool env, which each hook's env overrides. Hook
+// counts are added to res.
+func (i *Installer) runToolHooks(ctx context.Context, hookRunner *hooks.Runner, toolName, stateKey string, hookType hooks.HookType, hookList []config.Hook, env map[string]string, res *ToolResult) error {
+	var lastErr error
+	var failedHook config.Hook
+	for n, hook := range hookList {
+		script, sourceErr := hook.Source()
+		if sourceErr == nil && script == "" {
+			continue
+		}
+		ctx := config.WithLogAttrs(ctx, "phase", string(hookType), "hook", hookLabel(hook))
+
+		i.emit(Event{Type: EventHookStarted, Tool: toolName, HookType: hookType, Script: script, Description: hook.Description})
+		start := time.Now()
+		key := hookKey(stateKey, string(hookType), n)
+		opts := hooks.HookOptions(hook, env)
+		var result *hooks.HookResult
+		var err error
+		if sourceErr != nil {
+			result = &hooks.HookResult{ToolName: stateKey, HookType: hookType, Status: hooks.HookFailed, Error: sourceErr}
+			err = sourceErr
+		} else if i.checkpoint.hookDone(key) {
+			// Done before the resumed run was interrupted
+			result = &hooks.HookResult{ToolName: stateKey, HookType: hookType, Script: script, Status: hooks.HookSkipped, Skipped: true, SkipReason: "done before the resumed run"}
+		} else {
+			result, err = hookRunner.RunWithOptions(ctx, stateKey, hookType, script, opts)
+			err = i.masker.err(err)
+			if result != nil && result.ContinuedOnError {
+				config.WarnContext(ctx, "%s hook for %s failed, continuing (continue_on_error): %v", hookType, toolName, i.masker.err(result.Error))
+			}
+			if err == nil {
+				if cerr := i.checkpoint.completeHook(key); cerr != nil {
+					config.WarnContext(ctx, "%v", cerr)
+				}
+			}
+		}
+		i.hookFinished(ctx, toolName, hookLabel(hook), Event{
+			HookType:    hookType,
+			Script:      script,
+			Description: hook.Description,
+			HookResult:  result,
+			Env:         opts.Environ(),
+			Err:         err,
+		}, start, res)
+		if err != nil {
+			lastErr = err
+			failedHook = hook
+		}
+	}
+
+	if lastErr != nil {
+		desc := ""
+		if failedHook.Description != "" {
+			desc = fmt.Sprintf(" (%s)", failedHook.Description)
+		}
+		return classify(ErrorHook, fmt.Errorf("%s hook%s failed for %s: %w", hookType, desc, toolName, lastErr))
+	}
+	return nil
+}
+
+// hookFinished emits EventHookFinished for a hook of toolName, counts it in
+// res, and logs and traces it with the attributes of ctx. e carries the hook
+// fields of the event.
+func (i *Installer) hookFinished(ctx context.Context, toolName, label string, e Event, start time.Time, res *ToolResult) {
+	result := e.HookResult
+	e.Type = EventHookFinished
+	e.Tool = toolName
+	if result != nil {
+		e.Duration = result.Duration
+	}
+	i.emit(e)
+
+	if result != nil && result.Skipped {
+		res.HooksSkipped++
+	} else {
+		res.HooksRun++
+	}
+	attrs := []any{"duration_ms", e.Duration.Milliseconds()}
+	if result != nil {
+		attrs = append(attrs, "status", string(result.Status), "exit_code", result.ExitCode, "attempts", result.Attempts, "skipped", result.Skipped)
+	}
+	if e.Err != nil {
+		attrs = append(attrs, "error", e.Err)
+	}
+	config.GetLogger().Slog().DebugContext(ctx, "hook finished", attrs...)
+	tracing.Record(ctx, "hook "+string(e.HookType), start, e.Duration, e.Err, spanAttrs(append([]any{"tool", toolName, "hook", label}, attrs...))...)
+}
+
+// spanAttrs converts slog-style key/value pairs to span attributes. Errors
+// are left out; spans record them separately.
+func spanAttrs(args []any) []tracing.Attr {
+	attrs := make([]tracing.Attr, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, _ := args[i].(string)
+		if _, isErr := args[i+1].(error); isErr {
+			continue
+		}
+		attrs = append(attrs, tracing.Attr{Key: key, Value: args[i+1]})
+	}
+	return attrs
+}
+
+// hookLabel names a hook in logs: its description, its file, or the first
+// line of its script
+func hookLabel(hook config.Hook) string {
+	if hook.Description != "" {
+		return hook.Description
+	}
+	if hook.File != "" {
+		return hook.File
+	}
+	return firstLine(hook.Run)
+}
+
+// logToolResult logs the outcome of a tool with the attributes of ctx
+func logToolResult(ctx context.Context, res *ToolResult, err error) {
+	attrs := []any{"action", res.Action, "duration_ms", res.Duration.Milliseconds(), "hooks_run", res.HooksRun, "hooks_skipped", res.HooksSkipped}
+	if err != nil {
+		attrs = append(attrs, "error", err, "error_class", ClassifyError(err))
+		config.GetLogger().Slog().DebugContext(ctx, "tool failed", attrs...)
+		return
+	}
+	config.GetLogger().Slog().DebugContext(ctx, "tool finished", attrs...)
+}
+
+// filterConfig leaves out disabled tools and tools and hooks for other platforms
+func (c *Client) filterConfig(cfg *config.Config) (*config.Config, []config.Exclusion) {
+	cfg, disabled := config.FilterDisabled(cfg)
+	cfg, unsupported := config.FilterForPlatform(cfg, c.platform)
+	return cfg, append(disabled, unsupported...)
+}
+
+// installOrder returns tools_order if given, or the dependency order
+func installOrder(cfg *config.Config) ([]string, error) {
+	if toolOrder := config.GetToolOrder(cfg); len(toolOrder) > 0 {
+		return toolOrder, nil
+	}
+
+	order, err := config.NewToolResolver(config.GetTools(cfg)).ResolveOrder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependency order: %w", err)
+	}
+	return order, nil
+}
+
+// unavailableDependency returns the first dependency of tool in unavailable, or ""
+func unavailableDependency(tool config.Tool, unavailable map[string]bool) string {
+	for _, dep := range tool.GetDependencies() {
+		if unavailable[dep.Name] {
+			return dep.Name
+		}
+	}
+	return ""
+}
+
+// textInstaller returns an installer printing progress to stdout, used by
+// the Client convenience methods
+func (c *Client) textInstaller(opts InstallOptions) *Installer {
+	return NewInstaller(c, WithOptions(opts), WithObserver(NewTextObserver(os.Stdout, os.Stderr, false)))
+}
+
+// InstallWithHooks installs a tool with preinstall/postinstall hooks.
+// Every entry of a versions list is installed; only the default is set globally.
+func (c *Client) InstallWithHooks(ctx context.Context, cfg *config.Config, toolName string) error {
+	return c.textInstaller(InstallOptions{}).InstallTool(ctx, cfg, toolName)
+}
+
+// InstallAllWithHooks installs all tools from config with hooks, respecting tools_order and dependencies.
+// A failing optional tool is reported as a warning and its dependents are skipped;
+// any other failure stops the run.
+func (c *Client) InstallAllWithHooks(ctx context.Context, cfg *config.Config, runPostinstallOnUpdate bool) error {
+	_, err := c.InstallAllWithOptions(ctx, cfg, InstallOptions{RunPostinstallOnUpdate: runPostinstallOnUpdate})
+	return err
+}
+
+// InstallAllWithOptions installs all tools from config with hooks and returns a
+// summary of the run, printing progress to stdout. See Installer.Install.
+func (c *Client) InstallAllWithOptions(ctx context.Context, cfg *config.Config, opts InstallOptions) (*Summary, error) {
+	return c.textInstaller(opts).Install(ctx, cfg)
+}
+
+// UpgradeAllWithOptions upgrades all tools from config and returns a summary of
+// the run, printing progress to stdout. See Installer.Upgrade.
+func (c *Client) UpgradeAllWithOptions(ctx context.Context, cfg *config.Config, opts InstallOptions) (*Summary, error) {
+	return c.textInstaller(opts).Upgrade(ctx, cfg)
+}
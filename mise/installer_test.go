@@ -0,0 +1,138 @@
+package mise
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mise-seq/config-loader/config"
+)
+
+func TestNewInstaller_Options(t *testing.T) {
+	installer := NewInstaller(NewClient(),
+		WithKeepGoing(true),
+		WithStateDir("/tmp/state"),
+		WithForceHooks(true),
+		WithPostinstallOnUpdate(true),
+	)
+
+	expected := InstallOptions{KeepGoing: true, StateDir: "/tmp/state", ForceHooks: true, RunPostinstallOnUpdate: true}
+	if installer.Options() != expected {
+		t.Errorf("Options() = %+v, expected %+v", installer.Options(), expected)
+	}
+}
+
+func TestInstaller_Events(t *testing.T) {
+	fakeMise(t, "broken")
+
+	cfg := &config.Config{
+		ToolsOrder: []string{"jq", "broken", "plugin"},
+		Tools: map[string]config.Tool{
+			"jq":     {Postinstall: []config.Hook{{Run: "echo hello"}}},
+			"broken": {Optional: true},
+			"plugin": {Depends: []string{"broken"}},
+		},
+	}
+
+	var events []Event
+	installer := NewInstaller(NewClient(),
+		WithStateDir(t.TempDir()),
+		WithObserver(ObserverFunc(func(e Event) { events = append(events, e) })),
+	)
+	if _, err := installer.Install(context.Background(), cfg); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	var got []string
+	for _, e := range events {
+		got = append(got, string(e.Type)+":"+e.Tool)
+	}
+	expected := []string{
+		"tool_started:jq",
+		"command_finished:jq",
+		"command_finished:jq",
+		"hook_started:jq",
+		"hook_finished:jq",
+		"tool_succeeded:jq",
+		"tool_started:broken",
+		"command_finished:broken",
+		"tool_failed:broken",
+		"tool_skipped:plugin",
+	}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Events = %v\nexpected %v", got, expected)
+	}
+
+	if cmd := strings.Join(events[1].Command, " "); cmd != "install jq@latest" {
+		t.Errorf("Expected install command, got %q", cmd)
+	}
+	if r := events[4].HookResult; r == nil || r.Stdout != "hello\n" || r.Skipped {
+		t.Errorf("Unexpected hook result %+v", r)
+	}
+	if failed := events[8]; !failed.Optional || !failed.Continuing || failed.Err == nil {
+		t.Errorf("Unexpected tool_failed event %+v", failed)
+	}
+}
+
+func TestChannelObserver(t *testing.T) {
+	ch := make(chan Event, 1)
+	ChannelObserver(ch).OnEvent(Event{Type: EventToolStarted, Tool: "jq"})
+	if e := <-ch; e.Type != EventToolStarted || e.Tool != "jq" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
+
+func TestInstaller_ConstraintNoLongerSatisfied(t *testing.T) {
+	logFile := fakeMise(t)
+	writeLs(t, logFile, `{"go": [{"version": "1.22.7", "installed": true, "active": true}]}`)
+
+	// The config moved from ">=1.20 <1.23" to "~1.23"
+	cfg := &config.Config{Tools: map[string]config.Tool{"go": {Version: "~1.23"}}}
+	client := NewClient()
+	client.remoteVersions["go"] = []string{"1.22.7", "1.23.0", "1.23.4", "1.24.1"}
+
+	plan, err := client.Plan(context.Background(), cfg, InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	var actions []string
+	for _, a := range plan.Actions {
+		actions = append(actions, a.String())
+	}
+	if len(plan.Actions) != 2 || plan.Actions[0].Type != ActionInstall || plan.Actions[0].Version != "1.23.4" ||
+		plan.Actions[1].Type != ActionSwitch || plan.Actions[1].From != "1.22.7" || plan.Actions[1].Version != "1.23.4" {
+		t.Errorf("Expected an install of and switch to 1.23.4, got %v", actions)
+	}
+
+	installer := NewInstaller(client, WithStateDir(t.TempDir()))
+	summary, err := installer.Install(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	calls := strings.Join(miseCalls(t, logFile), "\n")
+	for _, want := range []string{"install go@1.23.4", "use -g go@1.23.4"} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("Expected mise %q, got:\n%s", want, calls)
+		}
+	}
+	if strings.Contains(calls, "upgrade go") {
+		t.Errorf("Expected no mise upgrade for a tool outside its constraint, got:\n%s", calls)
+	}
+	if res := summary.Results[0]; res.Status != ToolSucceeded {
+		t.Errorf("Unexpected result %+v", res)
+	}
+
+	// Within the constraint, a managed tool takes the upgrade flow as before
+	writeLs(t, logFile, `{"go": [{"version": "1.23.4", "installed": true, "active": true}]}`)
+	if err := os.Truncate(logFile, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installer.Install(context.Background(), cfg); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	calls = strings.Join(miseCalls(t, logFile), "\n")
+	if !strings.Contains(calls, "upgrade go") || strings.Contains(calls, "use -g") {
+		t.Errorf("Expected only mise upgrade within the constraint, got:\n%s", calls)
+	}
+}
@@ -0,0 +1,166 @@
+package mise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/proc"
+)
+
+// InstalledVersion is one entry of mise ls for a tool
+type InstalledVersion struct {
+	Version          string `json:"version"`
+	RequestedVersion string `json:"requested_version,omitempty"`
+	Installed        bool   `json:"installed"`
+	Active           bool   `json:"active,omitempty"`
+}
+
+// Inventory is the mise ls view of the machine, keyed by tool name as
+// mise reports it ("ripgrep" for "ubi:BurntSushi/ripgrep")
+type Inventory map[string][]InstalledVersion
+
+// lsName returns the name mise ls uses for a tool spec such as
+// "ubi:BurntSushi/ripgrep[exe=rg]@14.1.0"
+func lsName(tool string) string {
+	name := strings.Split(tool, "@")[0]
+	if idx := strings.Index(name, "["); idx != -1 {
+		name = name[:idx]
+	}
+	if idx := strings.LastIndex(name, ":"); idx != -1 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// Inventory returns the tools known to mise (mise ls --json)
+func (c *Client) Inventory(ctx context.Context) (Inventory, error) {
+	result, err := c.ListWithOutput(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	inv := make(Inventory, len(result.Tools))
+	for name, versions := range result.Tools {
+		entries := make([]InstalledVersion, 0, len(versions))
+		for _, v := range versions {
+			entries = append(entries, InstalledVersion{
+				Version:          v.Version,
+				RequestedVersion: v.RequestedVersion,
+				Installed:        v.Installed,
+				Active:           v.Active,
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+		inv[name] = entries
+	}
+	return inv, nil
+}
+
+// Manages reports whether mise lists the tool at all
+func (inv Inventory) Manages(tool string) bool {
+	_, ok := inv[lsName(tool)]
+	return ok
+}
+
+// HasVersion reports whether a version of the tool is installed.
+// A version prefix such as "3.11" matches an installed "3.11.9"; "" and
+// "latest" match any installed version.
+func (inv Inventory) HasVersion(tool, version string) bool {
+	for _, v := range inv[lsName(tool)] {
+		if !v.Installed {
+			continue
+		}
+		if version == "" || version == "latest" || v.Version == version || v.RequestedVersion == version ||
+			strings.HasPrefix(v.Version, version+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns the active version of the tool, or ""
+func (inv Inventory) Active(tool string) string {
+	for _, v := range inv[lsName(tool)] {
+		if v.Active {
+			return v.Version
+		}
+	}
+	return ""
+}
+
+// runMise runs a mise command and captures its output
+func (c *Client) runMise(ctx context.Context, args ...string) *Result {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	cmd := proc.Command(ctx, "mise", args...)
+	cmd.Env = append(os.Environ(), commandEnv()...)
+
+	stdout := new(strings.Builder)
+	stderr := new(strings.Builder)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := runCommand(ctx, cmd)
+	result := &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		}
+		result.Error = fmt.Errorf("mise %s failed: %w", args[0], err)
+		if line := firstLine(result.Stderr); line != "" {
+			result.Error = fmt.Errorf("%w: %s", result.Error, line)
+		}
+	}
+	return result
+}
+
+// OutdatedTool is one entry of mise outdated
+type OutdatedTool struct {
+	Name      string `json:"name"`
+	Requested string `json:"requested"`
+	Current   string `json:"current"`
+	Latest    string `json:"latest"`
+}
+
+// Outdated returns the tools mise upgrade would change (mise outdated --json)
+func (c *Client) Outdated(ctx context.Context) (map[string]OutdatedTool, error) {
+	result := c.runMise(ctx, "outdated", "--json")
+	if result.Error != nil && result.Stdout == "" {
+		return nil, result.Error
+	}
+
+	outdated := make(map[string]OutdatedTool)
+	if strings.TrimSpace(result.Stdout) == "" {
+		return outdated, nil
+	}
+	if err := json.Unmarshal([]byte(result.Stdout), &outdated); err != nil {
+		return nil, fmt.Errorf("failed to parse mise outdated output: %w", err)
+	}
+	for name, tool := range outdated {
+		if tool.Name == "" {
+			tool.Name = name
+			outdated[name] = tool
+		}
+	}
+	return outdated, nil
+}
+
+// Uninstall removes an installed tool version (mise uninstall)
+func (c *Client) Uninstall(ctx context.Context, spec string) error {
+	return c.runMise(ctx, "uninstall", spec).Error
+}
@@ -0,0 +1,99 @@
+package mise
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mise-seq/config-loader/config"
+)
+
+// secretMasker replaces secret values from the environment (see
+// config.SecretValues)
+// in what the installer passes on, so that hook and mise output never carries
+// them into observers, reports, step logs, traces or returned errors
+type secretMasker struct {
+	replacer *strings.Replacer // nil without secrets
+}
+
+// newSecretMasker masks the secrets of the current environment
+func newSecretMasker() secretMasker {
+	secrets := config.SecretValues(os.Environ())
+	if len(secrets) == 0 {
+		return secretMasker{}
+	}
+	return secretMasker{replacer: config.NewMasker(secrets)}
+}
+
+// string masks s
+func (m secretMasker) string(s string) string {
+	if m.replacer == nil {
+		return s
+	}
+	return m.replacer.Replace(s)
+}
+
+// err masks the message of err, keeping it unwrappable
+func (m secretMasker) err(err error) error {
+	if err == nil || m.replacer == nil {
+		return err
+	}
+	msg := m.replacer.Replace(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &maskedError{msg: msg, err: err}
+}
+
+// result returns a masked copy of a mise command result
+func (m secretMasker) result(r *Result) *Result {
+	if r == nil || m.replacer == nil {
+		return r
+	}
+	masked := *r
+	masked.Stdout, masked.Stderr, masked.Error = m.string(r.Stdout), m.string(r.Stderr), m.err(r.Error)
+	return &masked
+}
+
+// event returns e with every text field masked. Results are copied, so the
+// installer's own copies keep the real output.
+func (m secretMasker) event(e Event) Event {
+	if m.replacer == nil {
+		return e
+	}
+	e.Script = m.string(e.Script)
+	e.Description = m.string(e.Description)
+	e.Reason = m.string(e.Reason)
+	e.Err = m.err(e.Err)
+	e.Command = m.strings(e.Command)
+	e.Env = m.strings(e.Env)
+	e.Result = m.result(e.Result)
+	if hr := e.HookResult; hr != nil {
+		masked := *hr
+		masked.Script, masked.Stdout, masked.Stderr = m.string(hr.Script), m.string(hr.Stdout), m.string(hr.Stderr)
+		masked.Error = m.err(hr.Error)
+		masked.Command = m.strings(hr.Command)
+		e.HookResult = &masked
+	}
+	return e
+}
+
+// strings returns a masked copy of list
+func (m secretMasker) strings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	masked := make([]string, len(list))
+	for i, s := range list {
+		masked[i] = m.string(s)
+	}
+	return masked
+}
+
+// maskedError is an error whose message had secrets masked
+type maskedError struct {
+	msg string
+	err error
+}
+
+func (e *maskedError) Error() string { return e.msg }
+func (e *maskedError) Unwrap() error { return e.err }
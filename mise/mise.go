@@ -3,6 +3,7 @@ package mise
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,18 +11,31 @@ import (
 	"time"
 
 	"github.com/mise-seq/config-loader/config"
-	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/proc"
+	"github.com/mise-seq/config-loader/tracing"
 )
 
 // Client wraps mise CLI invocations
 type Client struct {
 	timeout time.Duration
+
+	// remoteVersions caches mise ls-remote output per tool
+	remoteVersions map[string][]string
+
+	// resolvedVersions caches constraint resolutions per "tool@constraint"
+	resolvedVersions map[string]string
+
+	// platform is used to filter platform-conditional tools and hooks
+	platform config.Platform
 }
 
 // NewClient creates a new mise client
 func NewClient() *Client {
 	return &Client{
-		timeout: 10 * time.Minute,
+		timeout:          10 * time.Minute,
+		remoteVersions:   make(map[string][]string),
+		resolvedVersions: make(map[string]string),
+		platform:         config.DetectPlatform(),
 	}
 }
 
@@ -30,25 +44,69 @@ func (c *Client) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
 }
 
-// getMiseEnv returns common environment variables for mise commands
-func getMiseEnv() []string {
+// SetPlatform overrides the detected platform used to filter tools and hooks
+func (c *Client) SetPlatform(platform config.Platform) {
+	c.platform = platform
+}
+
+// Platform returns the platform used to filter tools and hooks
+func (c *Client) Platform() config.Platform {
+	return c.platform
+}
+
+// globalConfigFile returns the global mise config file used by mise-seq
+func globalConfigFile() string {
 	miseDataDir := os.Getenv("MISE_DATA_DIR")
 	if miseDataDir == "" {
 		miseDataDir = os.ExpandEnv("$HOME/.local/share/mise")
 	}
+	return miseDataDir + "/config.toml"
+}
+
+// getMiseEnv returns common environment variables for mise commands
+func getMiseEnv() []string {
 	return []string{
 		"MISE_QUIET=1",
 		"MISE_DISABLE_WARNINGS=1",
 		"MISE_EXPERIMENTAL=true",
-		"MISE_GLOBAL_CONFIG_FILE=" + miseDataDir + "/config.toml",
+		"MISE_GLOBAL_CONFIG_FILE=" + globalConfigFile(),
 	}
 }
 
+// commandEnv returns getMiseEnv plus the tokens for mise commands that
+// download tools
+func commandEnv() []string {
+	return append(getMiseEnv(),
+		"GITHUB_TOKEN="+os.Getenv("GH_TOKEN"),
+		"GITLAB_TOKEN="+os.Getenv("GITLAB_TOKEN"),
+	)
+}
+
+// runCommand runs a mise subprocess in a trace span named after its
+// subcommand
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	name := "mise"
+	if len(cmd.Args) > 1 {
+		name += " " + cmd.Args[1]
+	}
+	_, span := tracing.Start(ctx, name, tracing.Attr{Key: "command", Value: strings.Join(cmd.Args, " ")})
+	err := proc.Run(cmd)
+	if cmd.ProcessState != nil {
+		span.SetAttr("exit_code", cmd.ProcessState.ExitCode())
+	}
+	span.Finish(err)
+	return err
+}
+
+// ErrNotInRegistry is returned when mise does not know a tool
+var ErrNotInRegistry = errors.New("not found in mise registry")
+
 // Result represents the result of a mise command
 type Result struct {
 	Stdout   string
 	Stderr   string
 	ExitCode int
+	Duration time.Duration
 	Error    error
 }
 
@@ -60,12 +118,8 @@ func (c *Client) InstallWithOutput(ctx context.Context, tool string) (*Result, e
 		defer cancel()
 	}
 
-	cmd := exec.CommandContext(ctx, "mise", "install", tool)
-	cmd.Env = append(os.Environ(), getMiseEnv()...)
-	cmd.Env = append(cmd.Env,
-		"GITHUB_TOKEN="+os.Getenv("GH_TOKEN"),
-		"GITLAB_TOKEN="+os.Getenv("GITLAB_TOKEN"),
-	)
+	cmd := proc.Command(ctx, "mise", "install", tool)
+	cmd.Env = append(os.Environ(), commandEnv()...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 
@@ -74,18 +128,23 @@ func (c *Client) InstallWithOutput(ctx context.Context, tool string) (*Result, e
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
-	err := cmd.Run()
+	start := time.Now()
+	err := runCommand(ctx, cmd)
 
 	result := &Result{
 		Stdout:   stdout.String(),
 		Stderr:   stderr.String(),
 		ExitCode: 0,
+		Duration: time.Since(start),
 	}
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
 			result.Error = fmt.Errorf("mise install failed: %w", err)
+			if strings.Contains(result.Stderr, "not found in mise tool registry") {
+				result.Error = fmt.Errorf("tool %s %w", tool, ErrNotInRegistry)
+			}
 		} else {
 			result.Error = err
 		}
@@ -101,8 +160,11 @@ func (c *Client) IsInstalled(ctx context.Context, tool string) (bool, error) {
 		return false, fmt.Errorf("failed to list tools: %w", err)
 	}
 
-	// Parse tool name from "runtime:tool" format
+	// Parse tool name from "runtime:tool[options]" format
 	targetTool := strings.Split(tool, "@")[0]
+	if idx := strings.Index(targetTool, "["); idx != -1 {
+		targetTool = targetTool[:idx]
+	}
 	if idx := strings.LastIndex(targetTool, ":"); idx != -1 {
 		targetTool = targetTool[idx+1:]
 	}
@@ -130,7 +192,7 @@ func (c *Client) InstallIfNotInstalled(ctx context.Context, tool string) (bool,
 	if err != nil {
 		// Check if it's a "not found in mise tool registry" error
 		if result != nil && strings.Contains(result.Stderr, "not found in mise tool registry") {
-			return false, nil, fmt.Errorf("tool %s not found in mise registry", tool)
+			return false, nil, fmt.Errorf("tool %s %w", tool, ErrNotInRegistry)
 		}
 		return false, nil, err
 	}
@@ -145,19 +207,15 @@ func (c *Client) SetGlobal(ctx context.Context, tool string) error {
 		defer cancel()
 	}
 
-	cmd := exec.CommandContext(ctx, "mise", "use", "-g", tool)
-	cmd.Env = append(os.Environ(), getMiseEnv()...)
-	cmd.Env = append(cmd.Env,
-		"GITHUB_TOKEN="+os.Getenv("GH_TOKEN"),
-		"GITLAB_TOKEN="+os.Getenv("GITLAB_TOKEN"),
-	)
+	cmd := proc.Command(ctx, "mise", "use", "-g", tool)
+	cmd.Env = append(os.Environ(), commandEnv()...)
 
 	stdout := new(strings.Builder)
 	stderr := new(strings.Builder)
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
-	err := cmd.Run()
+	err := runCommand(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("mise use -g failed: %s", stderr.String())
 	}
@@ -167,22 +225,11 @@ func (c *Client) SetGlobal(ctx context.Context, tool string) error {
 // IsManagedByMise checks if a tool is already managed by mise (shim exists)
 // Uses mise ls --json to check if tool is in the list
 func (c *Client) IsManagedByMise(ctx context.Context, tool string) bool {
-	result, err := c.ListWithOutput(ctx)
+	inv, err := c.Inventory(ctx)
 	if err != nil {
 		return false
 	}
-
-	// Parse tool name from "runtime:tool" format
-	targetTool := strings.Split(tool, "@")[0]
-	if idx := strings.LastIndex(targetTool, ":"); idx != -1 {
-		targetTool = targetTool[idx+1:]
-	}
-
-	// Check if tool exists in the map
-	if _, exists := result.Tools[targetTool]; exists {
-		return true
-	}
-	return false
+	return inv.Manages(tool)
 }
 
 // UpgradeWithOutput upgrades a tool and captures output
@@ -193,7 +240,7 @@ func (c *Client) UpgradeWithOutput(ctx context.Context, tool string) (*Result, e
 		defer cancel()
 	}
 
-	cmd := exec.CommandContext(ctx, "mise", "upgrade", tool)
+	cmd := proc.Command(ctx, "mise", "upgrade", tool)
 	cmd.Env = append(os.Environ(), getMiseEnv()...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
@@ -203,12 +250,14 @@ func (c *Client) UpgradeWithOutput(ctx context.Context, tool string) (*Result, e
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
-	err := cmd.Run()
+	start := time.Now()
+	err := runCommand(ctx, cmd)
 
 	result := &Result{
 		Stdout:   stdout.String(),
 		Stderr:   stderr.String(),
 		ExitCode: 0,
+		Duration: time.Since(start),
 	}
 
 	if err != nil {
@@ -265,7 +314,7 @@ func (c *Client) ListWithOutput(ctx context.Context) (*ListResult, error) {
 		defer cancel()
 	}
 
-	cmd := exec.CommandContext(ctx, "mise", "ls", "--json")
+	cmd := proc.Command(ctx, "mise", "ls", "--json")
 	cmd.Env = append(os.Environ(), getMiseEnv()...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
@@ -275,7 +324,7 @@ func (c *Client) ListWithOutput(ctx context.Context) (*ListResult, error) {
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
-	err := cmd.Run()
+	err := runCommand(ctx, cmd)
 
 	result := &ListResult{
 		Tools: make(map[string][]struct {
@@ -374,168 +423,21 @@ func (c *Client) List(ctx context.Context) error {
 	return nil
 }
 
-// InstallWithHooks installs a tool with preinstall/postinstall hooks
-func (c *Client) InstallWithHooks(ctx context.Context, cfg *config.Config, toolName string) error {
-	hookRunner := hooks.NewRunner(false)
-	tool, exists := cfg.Tools[toolName]
-	if !exists {
-		return fmt.Errorf("tool %s not found in config", toolName)
-	}
-
-	// Run preinstall hooks
-	if len(tool.Preinstall) > 0 {
-		scripts := ExtractHookScripts(tool.Preinstall)
-		results, err := hookRunner.RunHooks(ctx, toolName, hooks.HookTypePreinstall, scripts)
-		if err != nil {
-			// Output all hook output on error
-			for _, result := range results {
-				fmt.Print(result.Stdout)
-				fmt.Fprint(os.Stderr, result.Stderr)
-			}
-			desc := ""
-			if len(tool.Preinstall) > 0 && tool.Preinstall[0].Description != "" {
-				desc = fmt.Sprintf(" (%s)", tool.Preinstall[0].Description)
-			}
-			return fmt.Errorf("preinstall hook%s failed for %s: %w", desc, toolName, err)
-		}
-		// Output on success too
-		for _, result := range results {
-			if result.Stdout != "" {
-				fmt.Print(result.Stdout)
-			}
-			if result.Stderr != "" {
-				fmt.Fprint(os.Stderr, result.Stderr)
-			}
-		}
-	}
-
-	// Install tool
-	// Use toolName (full spec) for mise install, exeName is for reference only
-	toolSpec := fmt.Sprintf("%s@%s", toolName, tool.Version)
-	_, result, err := c.InstallIfNotInstalled(ctx, toolSpec)
+// IsVersionInstalled checks if a specific version of a tool is installed.
+// A version prefix such as "3.11" matches an installed "3.11.9".
+func (c *Client) IsVersionInstalled(ctx context.Context, tool, version string) (bool, error) {
+	inv, err := c.Inventory(ctx)
 	if err != nil {
-		// Check if it's a "not found" error - skip this tool
-		if strings.Contains(err.Error(), "not found in mise registry") {
-			fmt.Printf("[WARN] Tool %s not found in mise registry, skipping\n", toolName)
-			return nil
-		}
-		return fmt.Errorf("install failed for %s: %w", toolName, err)
-	}
-	if result != nil && result.Error != nil {
-		// Check if it's a "not found" error - skip this tool
-		if strings.Contains(result.Error.Error(), "not found in mise registry") {
-			fmt.Printf("[WARN] Tool %s not found in mise registry, skipping\n", toolName)
-			return nil
-		}
-		return fmt.Errorf("install error for %s: %w", toolName, result.Error)
-	}
-
-	// Set as global default (equivalent to mise use -g)
-	if err := c.SetGlobal(ctx, toolSpec); err != nil {
-		return fmt.Errorf("failed to set global default for %s: %w", toolName, err)
+		return false, err
 	}
-
-	// Run postinstall hooks
-	if len(tool.Postinstall) > 0 {
-		scripts := ExtractHookScripts(tool.Postinstall)
-		results, err := hookRunner.RunHooks(ctx, toolName, hooks.HookTypePostinstall, scripts)
-		if err != nil {
-			// Output all hook output on error
-			for _, result := range results {
-				fmt.Print(result.Stdout)
-				fmt.Fprint(os.Stderr, result.Stderr)
-			}
-			desc := ""
-			if len(tool.Postinstall) > 0 && tool.Postinstall[0].Description != "" {
-				desc = fmt.Sprintf(" (%s)", tool.Postinstall[0].Description)
-			}
-			return fmt.Errorf("postinstall hook%s failed for %s: %w", desc, toolName, err)
-		}
-		// Output on success too
-		for _, result := range results {
-			if result.Stdout != "" {
-				fmt.Print(result.Stdout)
-			}
-			if result.Stderr != "" {
-				fmt.Fprint(os.Stderr, result.Stderr)
-			}
-		}
-	}
-
-	return nil
+	return inv.HasVersion(tool, version), nil
 }
 
-// InstallAllWithHooks installs all tools from config with hooks, respecting tools_order and dependencies
-func (c *Client) InstallAllWithHooks(ctx context.Context, cfg *config.Config, runPostinstallOnUpdate bool) error {
-	toolOrder := config.GetToolOrder(cfg)
-	tools := config.GetTools(cfg)
-
-	// Determine installation order
-	var installOrder []string
-
-	if len(toolOrder) > 0 {
-		// Use tools_order if specified
-		installOrder = toolOrder
-	} else {
-		// Use dependency resolver
-		resolver := config.NewToolResolver(tools)
-		var err error
-		installOrder, err = resolver.ResolveOrder()
-		if err != nil {
-			return fmt.Errorf("failed to resolve dependency order: %w", err)
-		}
+// hookStateKey returns the key used for hook state of a tool version.
+// Tools with a versions list keep separate state per version.
+func hookStateKey(toolName string, tool config.Tool, version config.Version) string {
+	if !tool.HasMultipleVersions() {
+		return toolName
 	}
-
-	// Install in determined order
-	for _, name := range installOrder {
-		tool, exists := tools[name]
-		if !exists {
-			continue
-		}
-
-		// Check if already managed by mise
-		if c.IsManagedByMise(ctx, name) {
-			// Tool is already managed - run update flow
-			fmt.Printf("Upgrading %s (already managed by mise)\n", name)
-			_, err := c.UpgradeWithOutput(ctx, name)
-			if err != nil {
-				return fmt.Errorf("failed to upgrade %s: %w", name, err)
-			}
-
-			// Run postinstall hooks (update phase)
-			if runPostinstallOnUpdate && len(tool.Postinstall) > 0 {
-				hookRunner := hooks.NewRunnerWithOptions(false, "", false, runPostinstallOnUpdate)
-				scripts := ExtractHookScripts(tool.Postinstall)
-				results, err := hookRunner.RunHooks(ctx, name, hooks.HookTypePostinstall, scripts)
-				if err != nil {
-					for _, result := range results {
-						fmt.Print(result.Stdout)
-						fmt.Fprint(os.Stderr, result.Stderr)
-					}
-					desc := ""
-					if len(tool.Postinstall) > 0 && tool.Postinstall[0].Description != "" {
-						desc = fmt.Sprintf(" (%s)", tool.Postinstall[0].Description)
-					}
-					return fmt.Errorf("postinstall hook%s failed for %s: %w", desc, name, err)
-				}
-				// Output on success too
-				for _, result := range results {
-					if result.Stdout != "" {
-						fmt.Print(result.Stdout)
-					}
-					if result.Stderr != "" {
-						fmt.Fprint(os.Stderr, result.Stderr)
-					}
-				}
-			}
-		} else {
-			// Tool is not managed - run install flow
-			fmt.Printf("Installing %s\n", name)
-			if err := c.InstallWithHooks(ctx, cfg, name); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+	return toolName + "@" + string(version)
 }
@@ -0,0 +1,38 @@
+package mise
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToolSpecWithOptions builds a mise tool spec with inline options,
+// e.g. "ubi:BurntSushi/ripgrep[exe=rg]@14.1.0". mise cannot quote options,
+// so config validation rejects values that would change the spec.
+func ToolSpecWithOptions(tool, version string, options map[string]interface{}) string {
+	if len(options) == 0 {
+		return fmt.Sprintf("%s@%s", tool, version)
+	}
+
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, options[key]))
+	}
+	return fmt.Sprintf("%s[%s]@%s", tool, strings.Join(pairs, ","), version)
+}
+
+// SetGlobalWithOptions sets a tool as global default (mise use -g), passing
+// its tool options inline so mise writes them into the global config entry:
+//
+//	[tools]
+//	"ubi:BurntSushi/ripgrep" = { version = "14.1.0", exe = "rg" }
+func (c *Client) SetGlobalWithOptions(ctx context.Context, tool, version string, options map[string]interface{}) error {
+	return c.SetGlobal(ctx, ToolSpecWithOptions(tool, version, options))
+}
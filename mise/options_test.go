@@ -0,0 +1,41 @@
+package mise
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestToolSpecWithOptions(t *testing.T) {
+	tests := []struct {
+		tool     string
+		version  string
+		options  map[string]interface{}
+		expected string
+	}{
+		{"jq", "latest", nil, "jq@latest"},
+		{"ubi:BurntSushi/ripgrep", "14.1.0", map[string]interface{}{"exe": "rg"}, "ubi:BurntSushi/ripgrep[exe=rg]@14.1.0"},
+		{"ubi:foo/bar", "1.0", map[string]interface{}{"matching": "musl", "exe": "bar"}, "ubi:foo/bar[exe=bar,matching=musl]@1.0"},
+	}
+
+	for _, tt := range tests {
+		if result := ToolSpecWithOptions(tt.tool, tt.version, tt.options); result != tt.expected {
+			t.Errorf("ToolSpecWithOptions(%s) = %s, expected %s", tt.tool, result, tt.expected)
+		}
+	}
+}
+
+func TestSetGlobalWithOptions(t *testing.T) {
+	logFile := fakeMise(t)
+
+	err := NewClient().SetGlobalWithOptions(context.Background(), "ubi:BurntSushi/ripgrep", "14.1.0", map[string]interface{}{"exe": "rg"})
+	if err != nil {
+		t.Fatalf("SetGlobalWithOptions failed: %v", err)
+	}
+	if calls := miseCalls(t, logFile); calls[len(calls)-1] != "use -g ubi:BurntSushi/ripgrep[exe=rg]@14.1.0" {
+		t.Errorf("Expected the options to be passed to mise use, got %v", calls)
+	}
+	if _, err := os.Stat(globalConfigFile()); err == nil {
+		t.Error("Expected mise-seq to leave the global config file to mise")
+	}
+}
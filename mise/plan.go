@@ -0,0 +1,783 @@
+package mise
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/proc"
+	"github.com/mise-seq/config-loader/tracing"
+)
+
+// PlanFormatVersion is the version of the serialized plan format
+const PlanFormatVersion = 1
+
+// ActionType is the kind of a planned action
+type ActionType string
+
+const (
+	ActionSetting   ActionType = "setting"   // mise settings set
+	ActionHook      ActionType = "hook"      // run a preinstall/postinstall script
+	ActionInstall   ActionType = "install"   // mise install
+	ActionSwitch    ActionType = "switch"    // mise use -g (activate a version globally)
+	ActionUpgrade   ActionType = "upgrade"   // install and activate the version mise outdated reported
+	ActionUninstall ActionType = "uninstall" // mise uninstall
+)
+
+// PlannedHook is a hook script to run, rendered as it will be executed
+type PlannedHook struct {
+	Type        hooks.HookType `json:"type"`
+	StateKey    string         `json:"state_key"`
+	Script      string         `json:"script"`
+	Description string         `json:"description,omitempty"`
+
+	// Execution settings of the configured hook. File is informational:
+	// Script holds its contents when the plan was made, and apply runs those.
+	Shell           config.Shell `json:"shell,omitempty"`
+	File            string       `json:"file,omitempty"`
+	Timeout         string       `json:"timeout,omitempty"`
+	Retries         int          `json:"retries,omitempty"`
+	RetryDelay      string       `json:"retry_delay,omitempty"`
+	ContinueOnError bool         `json:"continue_on_error,omitempty"`
+
+	// Cwd is resolved; Env merges the defaults, tool and hook blocks
+	Cwd string            `json:"cwd,omitempty"`
+	Env map[string]string `json:"env,omitempty"`
+
+	// Guards are checked again when the plan is applied; paths are resolved
+	Creates string `json:"creates,omitempty"`
+	Removes string `json:"removes,omitempty"`
+	Unless  string `json:"unless,omitempty"`
+	OnlyIf  string `json:"only_if,omitempty"`
+}
+
+// options returns how the hook runs
+func (h *PlannedHook) options() hooks.Options {
+	return hooks.HookOptions(config.Hook{
+		Cwd:             h.Cwd,
+		Env:             h.Env,
+		Creates:         h.Creates,
+		Removes:         h.Removes,
+		Unless:          h.Unless,
+		OnlyIf:          h.OnlyIf,
+		Shell:           h.Shell,
+		Timeout:         h.Timeout,
+		Retries:         h.Retries,
+		RetryDelay:      h.RetryDelay,
+		ContinueOnError: h.ContinueOnError,
+	})
+}
+
+// Action is one step of a plan
+type Action struct {
+	Type    ActionType             `json:"type"`
+	Tool    string                 `json:"tool,omitempty"`
+	Version string                 `json:"version,omitempty"` // resolved target version
+	From    string                 `json:"from,omitempty"`    // version before the action
+	Spec    string                 `json:"spec,omitempty"`    // mise tool spec passed to install and upgrade
+	Options map[string]interface{} `json:"options,omitempty"` // tool options passed by switch and upgrade
+	Hook    *PlannedHook           `json:"hook,omitempty"`
+	Setting *Setting               `json:"setting,omitempty"`
+}
+
+// String describes the action in one line
+func (a Action) String() string {
+	switch a.Type {
+	case ActionSetting:
+		if a.From != "" {
+			return fmt.Sprintf("set %s = %s (was %s)", a.Setting.Key, a.Setting.Value, a.From)
+		}
+		return fmt.Sprintf("set %s = %s", a.Setting.Key, a.Setting.Value)
+	case ActionHook:
+		desc := ""
+		if a.Hook.Description != "" {
+			desc = fmt.Sprintf(" (%s)", a.Hook.Description)
+		}
+		return fmt.Sprintf("run %s hook for %s%s: %s", a.Hook.Type, a.Tool, desc, firstLine(a.Hook.Script))
+	case ActionInstall:
+		return fmt.Sprintf("install %s", a.Spec)
+	case ActionSwitch:
+		if a.From != "" {
+			return fmt.Sprintf("activate %s@%s globally (was %s)", a.Tool, a.Version, a.From)
+		}
+		return fmt.Sprintf("activate %s@%s globally", a.Tool, a.Version)
+	case ActionUpgrade:
+		return fmt.Sprintf("upgrade %s %s -> %s", a.Tool, a.From, a.Version)
+	case ActionUninstall:
+		return fmt.Sprintf("uninstall %s@%s", a.Tool, a.Version)
+	}
+	return string(a.Type)
+}
+
+// MachineState is the part of the machine a plan depends on. Apply refuses
+// to run a plan when the current state differs from the planned one.
+type MachineState struct {
+	Tools    Inventory         `json:"tools"`
+	Hooks    map[string]string `json:"hooks,omitempty"`    // "<state key>/<hook type>" -> marker hash
+	Settings map[string]string `json:"settings,omitempty"` // setting key -> value
+}
+
+// Plan is every action an install would take, computed up front
+type Plan struct {
+	Version    int                `json:"version"`
+	CreatedAt  time.Time          `json:"created_at"`
+	ConfigPath string             `json:"config_path,omitempty"`
+	ConfigHash string             `json:"config_hash,omitempty"`
+	Platform   string             `json:"platform"`
+	Options    InstallOptions     `json:"options"`
+	State      MachineState       `json:"state"`
+	Excluded   []config.Exclusion `json:"excluded,omitempty"`
+	Actions    []Action           `json:"actions"`
+}
+
+// planner accumulates actions and the state they depend on
+type planner struct {
+	c        *Client
+	opts     InstallOptions
+	inv      Inventory
+	outdated map[string]OutdatedTool
+	env      map[string]string // defaults env
+	plan     *Plan
+}
+
+// Plan computes the actions an install of cfg would take on this machine,
+// based on mise ls, mise outdated, hook state and mise settings
+func (c *Client) Plan(ctx context.Context, cfg *config.Config, opts InstallOptions) (*Plan, error) {
+	cfg, excluded := c.filterConfig(cfg)
+	order, err := installOrder(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := c.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Upgrades are planned to the version mise outdated reports, so that
+	// apply installs that version rather than whatever is newest by then
+	outdated, err := c.Outdated(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outdated tools: %w", err)
+	}
+
+	p := &planner{
+		c:        c,
+		opts:     opts,
+		inv:      inv,
+		outdated: outdated,
+		env:      cfg.DefaultsEnv(),
+		plan: &Plan{
+			Version:   PlanFormatVersion,
+			CreatedAt: time.Now().UTC(),
+			Platform:  c.platform.String(),
+			Options:   opts,
+			Excluded:  excluded,
+			State: MachineState{
+				Tools:    inv,
+				Hooks:    make(map[string]string),
+				Settings: make(map[string]string),
+			},
+		},
+	}
+
+	if err := p.planSettings(ctx, cfg); err != nil {
+		return nil, err
+	}
+	if err := p.planDefaults(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	tools := config.GetTools(cfg)
+	for _, name := range order {
+		tool, exists := tools[name]
+		if !exists {
+			continue
+		}
+		if err := p.planTool(ctx, name, tool); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.plan, nil
+}
+
+// planSettings adds the settings whose value differs from the configured one
+func (p *planner) planSettings(ctx context.Context, cfg *config.Config) error {
+	for _, setting := range ConfiguredSettings(cfg) {
+		current, err := p.c.GetSetting(ctx, setting.Key)
+		if err != nil {
+			current = ""
+		}
+		p.plan.State.Settings[setting.Key] = current
+		if current == setting.Value {
+			continue
+		}
+		s := setting
+		p.plan.Actions = append(p.plan.Actions, Action{Type: ActionSetting, Setting: &s, From: current})
+	}
+	return nil
+}
+
+// planDefaults adds the default preinstall hooks that would run
+func (p *planner) planDefaults(ctx context.Context, cfg *config.Config) error {
+	preinstall, _ := GetDefaultsHooks(cfg)
+	return p.planHooks(ctx, "defaults", "defaults", hooks.HookTypePreinstall, preinstall, p.env, false)
+}
+
+// planHooks adds the hooks of one type whose guards and state marker say
+// they would run; env is merged under each hook's own env
+func (p *planner) planHooks(ctx context.Context, toolName, stateKey string, hookType hooks.HookType, hookList []config.Hook, env map[string]string, runPostinstallOnUpdate bool) error {
+	if len(hookList) == 0 {
+		return nil
+	}
+
+	stateMgr := p.opts.stateManager(runPostinstallOnUpdate)
+	marker, err := stateMgr.ReadMarker(stateKey, string(hookType))
+	if err != nil {
+		return fmt.Errorf("failed to read hook state for %s: %w", stateKey, err)
+	}
+	p.plan.State.Hooks[stateKey+"/"+string(hookType)] = marker
+
+	for _, hook := range hookList {
+		script, err := hook.Source()
+		if err != nil {
+			return fmt.Errorf("%s hook of %s: %w", hookType, stateKey, err)
+		}
+		if script == "" {
+			continue
+		}
+		opts := hooks.HookOptions(hook, env)
+		reason, err := hooks.CheckGuards(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to check %s hook guards for %s: %w", hookType, stateKey, err)
+		}
+		if reason != "" {
+			continue
+		}
+		shouldRun, _, err := stateMgr.ShouldRunHook(stateKey, string(hookType), opts.StateContent(script))
+		if err != nil {
+			return fmt.Errorf("failed to check hook state for %s: %w", stateKey, err)
+		}
+		if !shouldRun && !opts.Guards.ChecksResult() {
+			continue
+		}
+		p.plan.Actions = append(p.plan.Actions, Action{
+			Type: ActionHook,
+			Tool: toolName,
+			Hook: &PlannedHook{
+				Type:        hookType,
+				StateKey:    stateKey,
+				Script:      script,
+				Description: hook.Description,
+
+				Shell:           hook.Shell,
+				File:            hook.FilePath(),
+				Timeout:         hook.Timeout,
+				Retries:         hook.Retries,
+				RetryDelay:      hook.RetryDelay,
+				ContinueOnError: hook.ContinueOnError,
+				Cwd:             opts.Dir,
+				Env:             opts.Env,
+				Creates:         opts.Guards.Creates,
+				Removes:         opts.Guards.Removes,
+				Unless:          opts.Guards.Unless,
+				OnlyIf:          opts.Guards.OnlyIf,
+			},
+		})
+	}
+	return nil
+}
+
+// planTool adds the actions for one tool, mirroring installOrUpgradeTool
+func (p *planner) planTool(ctx context.Context, name string, tool config.Tool) error {
+	if !tool.HasMultipleVersions() && p.inv.Manages(name) && !outsideConstraint(string(tool.Version), p.inv.Active(name)) {
+		if o, ok := p.outdated[lsName(name)]; ok {
+			p.plan.Actions = append(p.plan.Actions, Action{
+				Type:    ActionUpgrade,
+				Tool:    name,
+				Version: o.Latest,
+				From:    o.Current,
+				Spec:    ToolSpecWithOptions(name, o.Latest, tool.MiseOptions()),
+				Options: tool.MiseOptions(),
+			})
+		}
+		if p.opts.RunPostinstallOnUpdate {
+			return p.planHooks(ctx, name, name, hooks.HookTypePostinstall, tool.Postinstall, config.MergeEnv(p.env, tool.Env), true)
+		}
+		return nil
+	}
+
+	var wanted []string
+	for _, tv := range tool.InstallVersions() {
+		stateKey := hookStateKey(name, tool, tv.Version)
+		if err := p.planHooks(ctx, name, stateKey, hooks.HookTypePreinstall, tool.Preinstall, config.MergeEnv(p.env, tool.Env), false); err != nil {
+			return err
+		}
+
+		version, err := p.c.ResolveVersion(ctx, name, string(tv.Version))
+		if err != nil {
+			return fmt.Errorf("failed to resolve version for %s: %w", name, err)
+		}
+		wanted = append(wanted, version)
+
+		installed := false
+		if tool.HasMultipleVersions() || config.IsConstraint(string(tv.Version)) {
+			installed = p.inv.HasVersion(name, version)
+		} else {
+			installed = p.inv.Manages(name)
+		}
+		if !installed {
+			p.plan.Actions = append(p.plan.Actions, Action{
+				Type:    ActionInstall,
+				Tool:    name,
+				Version: version,
+				Spec:    ToolSpecWithOptions(name, version, tool.MiseOptions()),
+			})
+		}
+
+		if tv.Default {
+			active := p.inv.Active(name)
+			if !installed || active == "" || !versionMatches(active, version) {
+				p.plan.Actions = append(p.plan.Actions, Action{
+					Type:    ActionSwitch,
+					Tool:    name,
+					Version: version,
+					From:    active,
+					Options: tool.MiseOptions(),
+				})
+			}
+		}
+
+		if err := p.planHooks(ctx, name, stateKey, hooks.HookTypePostinstall, tool.Postinstall, config.MergeEnv(p.env, tool.Env), false); err != nil {
+			return err
+		}
+	}
+
+	if tool.HasMultipleVersions() {
+		p.planUninstalls(name, wanted)
+	}
+	return nil
+}
+
+// planUninstalls adds an uninstall for every installed version of a tool
+// with a versions list that is no longer in it. Tools missing from the config
+// are left alone: mise-seq cannot tell them from tools installed by hand.
+func (p *planner) planUninstalls(name string, wanted []string) {
+	for _, v := range p.inv[lsName(name)] {
+		if !v.Installed || slices.ContainsFunc(wanted, func(version string) bool { return versionMatches(v.Version, version) }) {
+			continue
+		}
+		p.plan.Actions = append(p.plan.Actions, Action{Type: ActionUninstall, Tool: name, Version: v.Version})
+	}
+}
+
+// versionMatches reports whether an installed version satisfies a requested one
+func versionMatches(installed, requested string) bool {
+	return requested == "" || requested == "latest" || installed == requested ||
+		strings.HasPrefix(installed, requested+".")
+}
+
+// Print writes a human-readable plan
+func (p *Plan) Print(w io.Writer) {
+	for _, ex := range p.Excluded {
+		fmt.Fprintf(w, "  ~ %s: %s\n", ex.Tool, ex.Reason)
+	}
+	if len(p.Actions) == 0 {
+		fmt.Fprintln(w, "No changes. The machine matches the config.")
+		return
+	}
+	for i, action := range p.Actions {
+		fmt.Fprintf(w, "%3d. %s\n", i+1, action)
+		if action.Type == ActionHook && strings.Contains(action.Hook.Script, "\n") {
+			for _, line := range strings.Split(action.Hook.Script, "\n") {
+				fmt.Fprintf(w, "       | %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d action(s)\n", len(p.Actions))
+}
+
+// WritePlan serializes a plan to a JSON file
+func WritePlan(path string, plan *Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// ReadPlan loads a plan written by WritePlan
+func ReadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if plan.Version != PlanFormatVersion {
+		return nil, fmt.Errorf("plan %s has format version %d, expected %d", path, plan.Version, PlanFormatVersion)
+	}
+	return &plan, nil
+}
+
+// captureState reads the current machine state for the keys recorded in plan
+func (c *Client) captureState(ctx context.Context, plan *Plan) (MachineState, error) {
+	inv, err := c.Inventory(ctx)
+	if err != nil {
+		return MachineState{}, err
+	}
+
+	state := MachineState{
+		Tools:    inv,
+		Hooks:    make(map[string]string, len(plan.State.Hooks)),
+		Settings: make(map[string]string, len(plan.State.Settings)),
+	}
+
+	stateMgr := plan.Options.stateManager(false)
+	for key := range plan.State.Hooks {
+		idx := strings.LastIndex(key, "/")
+		marker, err := stateMgr.ReadMarker(key[:idx], key[idx+1:])
+		if err != nil {
+			return MachineState{}, fmt.Errorf("failed to read hook state for %s: %w", key, err)
+		}
+		state.Hooks[key] = marker
+	}
+
+	for key := range plan.State.Settings {
+		value, err := c.GetSetting(ctx, key)
+		if err != nil {
+			value = ""
+		}
+		state.Settings[key] = value
+	}
+	return state, nil
+}
+
+// Diff lists the differences between a planned state and the current one
+func (s MachineState) Diff(current MachineState) []string {
+	var diffs []string
+
+	for _, name := range unionKeys(s.Tools, current.Tools) {
+		before, after := formatVersions(s.Tools[name]), formatVersions(current.Tools[name])
+		if before != after {
+			diffs = append(diffs, fmt.Sprintf("tool %s: %s -> %s", name, before, after))
+		}
+	}
+	for _, key := range unionKeys(s.Hooks, current.Hooks) {
+		if s.Hooks[key] != current.Hooks[key] {
+			diffs = append(diffs, fmt.Sprintf("hook state %s changed", key))
+		}
+	}
+	for _, key := range unionKeys(s.Settings, current.Settings) {
+		if s.Settings[key] != current.Settings[key] {
+			diffs = append(diffs, fmt.Sprintf("setting %s: %q -> %q", key, s.Settings[key], current.Settings[key]))
+		}
+	}
+	return diffs
+}
+
+// formatVersions formats installed versions for comparison, e.g. "[1.6 1.7*]"
+func formatVersions(versions []InstalledVersion) string {
+	if versions == nil {
+		return "absent"
+	}
+	labels := make([]string, 0, len(versions))
+	for _, v := range versions {
+		label := v.Version
+		if !v.Installed {
+			label += "?"
+		}
+		if v.Active {
+			label += "*"
+		}
+		labels = append(labels, label)
+	}
+	return "[" + strings.Join(labels, " ") + "]"
+}
+
+// unionKeys returns the sorted keys of two maps
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkPlan refuses a plan made for another platform, or whose config file
+// or machine state changed since planning
+func (c *Client) checkPlan(ctx context.Context, plan *Plan) error {
+	if plan.Platform != c.platform.String() {
+		return fmt.Errorf("plan was made for %s, this machine is %s", plan.Platform, c.platform)
+	}
+	if plan.ConfigPath != "" {
+		hash, err := config.HashFile(plan.ConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to check the config of the plan: %w", err)
+		}
+		if hash != plan.ConfigHash {
+			return fmt.Errorf("config %s changed since the plan was made; run plan again", plan.ConfigPath)
+		}
+	}
+
+	current, err := c.captureState(ctx, plan)
+	if err != nil {
+		return err
+	}
+	if diffs := plan.State.Diff(current); len(diffs) > 0 {
+		return fmt.Errorf("machine state changed since the plan was made; run plan again:\n  - %s",
+			strings.Join(diffs, "\n  - "))
+	}
+	return nil
+}
+
+// Apply executes a plan made by Plan without reporting progress; see
+// Installer.Apply
+func (c *Client) Apply(ctx context.Context, plan *Plan) error {
+	_, err := NewInstaller(c).Apply(ctx, plan)
+	return err
+}
+
+// Apply executes a plan made by Client.Plan and returns a summary of the run.
+// Like Install, it reports every tool, mise command and hook to the
+// observers; settings and default hooks belong to no tool. It refuses to run
+// if the config file or the machine state the plan depends on has changed
+// since planning, and stops at the first failing action: the tools after it
+// are skipped. Hooks run with the options of the plan.
+func (i *Installer) Apply(ctx context.Context, plan *Plan) (*Summary, error) {
+	summary := &Summary{}
+	if err := i.client.checkPlan(ctx, plan); err != nil {
+		return summary, err
+	}
+
+	for _, ex := range plan.Excluded {
+		i.emit(Event{Type: EventToolSkipped, Tool: ex.Tool, Reason: ex.Reason})
+		summary.add(&ToolResult{Tool: ex.Tool, Action: "-", Status: ToolSkipped, Reason: ex.Reason})
+	}
+
+	var abortErr error
+	for _, step := range applySteps(plan.Actions) {
+		if step.tool == "" {
+			if abortErr == nil && proc.Interrupted(ctx) == nil {
+				abortErr = i.applyActions(ctx, plan, step, &ToolResult{})
+			}
+			continue
+		}
+
+		res := summary.add(&ToolResult{Tool: step.tool, Action: step.action()})
+		if interrupt := proc.Interrupted(ctx); interrupt != nil {
+			res.Status = ToolSkipped
+			res.Reason = "not attempted: " + interrupt.Error()
+			i.emit(Event{Type: EventToolSkipped, Tool: step.tool, Reason: res.Reason})
+			continue
+		}
+		if abortErr != nil {
+			res.Status = ToolSkipped
+			res.Reason = "not attempted after an earlier failure"
+			i.emit(Event{Type: EventToolSkipped, Tool: step.tool, Reason: res.Reason})
+			continue
+		}
+
+		toolCtx := config.WithLogAttrs(ctx, "tool", step.tool)
+		toolCtx, span := tracing.Start(toolCtx, "tool "+step.tool, tracing.Attr{Key: "tool", Value: step.tool})
+		i.emit(Event{Type: EventToolStarted, Tool: step.tool, Action: res.Action})
+		start := time.Now()
+		err := i.applyActions(toolCtx, plan, step, res)
+		res.Duration = time.Since(start)
+		logToolResult(toolCtx, res, err)
+		span.SetAttr("action", res.Action)
+		if err != nil {
+			span.SetAttr("error_class", string(ClassifyError(err)))
+		}
+		span.Finish(err)
+		if err == nil {
+			res.Status = ToolSucceeded
+			i.emit(Event{Type: EventToolSucceeded, Tool: step.tool, Action: res.Action, Duration: res.Duration})
+			continue
+		}
+
+		res.Status = ToolFailed
+		res.Reason = err.Error()
+		res.ErrorClass = ClassifyError(err)
+		abortErr = err
+		i.emit(Event{
+			Type:     EventToolFailed,
+			Tool:     step.tool,
+			Action:   res.Action,
+			Duration: res.Duration,
+			Reason:   res.Reason,
+			Err:      err,
+		})
+	}
+
+	if interrupt := proc.Interrupted(ctx); interrupt != nil {
+		summary.Interrupted = interrupt.SignalName()
+		return summary, interrupt
+	}
+	return summary, abortErr
+}
+
+// applyStep is a run of consecutive actions of one tool; settings and
+// default hooks have no tool
+type applyStep struct {
+	tool    string
+	first   int // index of the first action in the plan
+	actions []Action
+}
+
+// action names the step in the summary: upgrade if it upgrades the tool,
+// otherwise install
+func (s applyStep) action() string {
+	for _, a := range s.actions {
+		if a.Type == ActionUpgrade {
+			return "upgrade"
+		}
+	}
+	return "install"
+}
+
+// applySteps splits the actions of a plan into steps
+func applySteps(actions []Action) []applyStep {
+	var steps []applyStep
+	for n, a := range actions {
+		tool := a.Tool
+		if a.Type == ActionSetting || (a.Type == ActionHook && a.Hook.StateKey == "defaults" && tool == "defaults") {
+			tool = ""
+		}
+		if len(steps) == 0 || steps[len(steps)-1].tool != tool {
+			steps = append(steps, applyStep{tool: tool, first: n})
+		}
+		steps[len(steps)-1].actions = append(steps[len(steps)-1].actions, a)
+	}
+	return steps
+}
+
+// applyActions executes the actions of a step until one fails, recording
+// hook counts in res. The error names the failing action and is masked.
+func (i *Installer) applyActions(ctx context.Context, plan *Plan, step applyStep, res *ToolResult) error {
+	for n, action := range step.actions {
+		err := i.applyAction(ctx, plan, step.tool, action, res)
+		if interrupt := proc.Interrupted(ctx); interrupt != nil && err != nil && !errors.Is(err, interrupt) {
+			// mise was stopped by the signal, not failing on its own
+			err = fmt.Errorf("%w: %w", interrupt, err)
+		}
+		if err != nil {
+			return i.masker.err(fmt.Errorf("action %d (%s) failed: %w", step.first+n+1, action, err))
+		}
+	}
+	return nil
+}
+
+// applyAction executes one planned action of tool, emitting its command or
+// hook events
+func (i *Installer) applyAction(ctx context.Context, plan *Plan, tool string, action Action, res *ToolResult) error {
+	c := i.client
+	switch action.Type {
+	case ActionSetting:
+		start := time.Now()
+		err := c.runMiseSettings(ctx, action.Setting.Key, action.Setting.Value)
+		i.commandFinished(ctx, tool, []string{"settings", "set", action.Setting.Key, action.Setting.Value}, getMiseEnv(), start, nil, err)
+		return err
+	case ActionHook:
+		return i.applyHook(ctx, plan, tool, action.Hook, res)
+	case ActionInstall:
+		return i.applyInstall(ctx, tool, action.Spec)
+	case ActionSwitch:
+		return i.applySwitch(ctx, tool, action)
+	case ActionUpgrade:
+		// Install and activate the planned version, like install and switch
+		if action.Version == "" {
+			return fmt.Errorf("upgrade of %s has no target version; run plan again", action.Tool)
+		}
+		if err := i.applyInstall(ctx, tool, action.Spec); err != nil {
+			return classify(ErrorUpgrade, err)
+		}
+		return i.applySwitch(ctx, tool, action)
+	case ActionUninstall:
+		spec := action.Tool + "@" + action.Version
+		start := time.Now()
+		err := c.Uninstall(ctx, spec)
+		i.commandFinished(ctx, tool, []string{"uninstall", spec}, getMiseEnv(), start, nil, err)
+		return err
+	}
+	return fmt.Errorf("unknown action type '%s'", action.Type)
+}
+
+// applyInstall runs mise install for a planned spec
+func (i *Installer) applyInstall(ctx context.Context, tool, spec string) error {
+	ctx = config.WithLogAttrs(ctx, "phase", "install")
+	start := time.Now()
+	result, err := i.client.InstallWithOutput(ctx, spec)
+	i.commandFinished(ctx, tool, []string{"install", spec}, commandEnv(), start, result, err)
+	if err == nil {
+		err = result.Error
+	}
+	if err != nil {
+		return classify(ErrorInstall, fmt.Errorf("install failed for %s: %w", spec, err))
+	}
+	return nil
+}
+
+// applySwitch activates the planned version of a tool globally
+func (i *Installer) applySwitch(ctx context.Context, tool string, action Action) error {
+	start := time.Now()
+	err := i.client.SetGlobalWithOptions(ctx, action.Tool, action.Version, action.Options)
+	i.commandFinished(ctx, tool, []string{"use", "-g", ToolSpecWithOptions(action.Tool, action.Version, action.Options)}, commandEnv(), start, nil, err)
+	if err != nil {
+		return classify(ErrorActivation, fmt.Errorf("failed to set global default for %s: %w", action.Tool, err))
+	}
+	return nil
+}
+
+// applyHook runs a planned hook with its state marker and guards
+func (i *Installer) applyHook(ctx context.Context, plan *Plan, tool string, hook *PlannedHook, res *ToolResult) error {
+	label := hook.Description
+	if label == "" {
+		label = firstLine(hook.Script)
+	}
+	ctx = config.WithLogAttrs(ctx, "phase", string(hook.Type), "hook", label)
+
+	i.emit(Event{Type: EventHookStarted, Tool: tool, HookType: hook.Type, Script: hook.Script, Description: hook.Description})
+	start := time.Now()
+	runner := plan.Options.hookRunner(hook.Type == hooks.HookTypePostinstall && plan.Options.RunPostinstallOnUpdate)
+	opts := hook.options()
+	result, err := runner.RunWithOptions(ctx, hook.StateKey, hook.Type, hook.Script, opts)
+	err = i.masker.err(err)
+	if result != nil && result.ContinuedOnError {
+		config.WarnContext(ctx, "%s hook for %s failed, continuing (continue_on_error): %v", hook.Type, hook.StateKey, i.masker.err(result.Error))
+	}
+	i.hookFinished(ctx, tool, label, Event{
+		HookType:    hook.Type,
+		Script:      hook.Script,
+		Description: hook.Description,
+		HookResult:  result,
+		Env:         opts.Environ(),
+		Err:         err,
+	}, start, res)
+	if err != nil {
+		return classify(ErrorHook, err)
+	}
+	return nil
+}
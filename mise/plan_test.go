@@ -0,0 +1,273 @@
+package mise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+)
+
+func planConfig() *config.Config {
+	return &config.Config{
+		ToolsOrder: []string{"jq", "yq"},
+		Tools: map[string]config.Tool{
+			"jq": {Version: "1.7"},
+			"yq": {Postinstall: []config.Hook{{Run: "yq --version", Description: "check"}}},
+		},
+		Settings: &config.Settings{Experimental: "true"},
+	}
+}
+
+func writeLs(t *testing.T, logFile, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(logFile), "ls.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write ls.json: %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	logFile := fakeMise(t)
+	writeLs(t, logFile, `{"jq": [{"version": "1.7.1", "installed": true, "active": true}]}`)
+
+	opts := InstallOptions{StateDir: t.TempDir()}
+	plan, err := NewClient().Plan(context.Background(), planConfig(), opts)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	var got []string
+	for _, action := range plan.Actions {
+		got = append(got, string(action.Type)+" "+action.Tool)
+	}
+	expected := []string{"setting ", "install yq", "switch yq", "hook yq"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Actions = %v, expected %v", got, expected)
+	}
+
+	if plan.Actions[1].Spec != "yq@latest" {
+		t.Errorf("Expected install spec yq@latest, got %s", plan.Actions[1].Spec)
+	}
+	if hook := plan.Actions[3].Hook; hook.Type != hooks.HookTypePostinstall || hook.Script != "yq --version" {
+		t.Errorf("Unexpected hook %+v", hook)
+	}
+	if _, ok := plan.State.Tools["jq"]; !ok {
+		t.Error("Expected the mise ls inventory in the plan state")
+	}
+}
+
+func TestPlan_RoundTrip(t *testing.T) {
+	fakeMise(t)
+
+	plan, err := NewClient().Plan(context.Background(), planConfig(), InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := WritePlan(path, plan); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+	loaded, err := ReadPlan(path)
+	if err != nil {
+		t.Fatalf("ReadPlan failed: %v", err)
+	}
+	if len(loaded.Actions) != len(plan.Actions) || loaded.Options.StateDir != plan.Options.StateDir {
+		t.Errorf("Plan did not round-trip: %+v", loaded)
+	}
+}
+
+func TestApply(t *testing.T) {
+	logFile := fakeMise(t)
+	stateDir := t.TempDir()
+
+	client := NewClient()
+	plan, err := client.Plan(context.Background(), planConfig(), InstallOptions{StateDir: stateDir})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if err := client.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	calls := strings.Join(miseCalls(t, logFile), "\n")
+	for _, want := range []string{"settings set experimental true", "install yq@latest", "install jq@1.7", "use -g yq@latest"} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("Expected mise %q, got:\n%s", want, calls)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "yq", "postinstall.sha256")); err != nil {
+		t.Errorf("Expected hook state to be saved: %v", err)
+	}
+}
+
+func TestInstaller_ApplyEvents(t *testing.T) {
+	fakeMise(t)
+
+	client := NewClient()
+	plan, err := client.Plan(context.Background(), planConfig(), InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	var events []Event
+	installer := NewInstaller(client, WithObserver(ObserverFunc(func(e Event) { events = append(events, e) })))
+	summary, err := installer.Apply(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	var got []string
+	for _, e := range events {
+		got = append(got, string(e.Type)+":"+e.Tool)
+	}
+	expected := []string{
+		"command_finished:",
+		"tool_started:jq",
+		"command_finished:jq",
+		"command_finished:jq",
+		"tool_succeeded:jq",
+		"tool_started:yq",
+		"command_finished:yq",
+		"command_finished:yq",
+		"hook_started:yq",
+		"hook_finished:yq",
+		"tool_succeeded:yq",
+	}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Events = %v\nexpected %v", got, expected)
+	}
+	if cmd := strings.Join(events[6].Command, " "); cmd != "install yq@latest" {
+		t.Errorf("Expected install command, got %q", cmd)
+	}
+	if r := events[9].HookResult; r == nil || r.Stdout != "yq 0.0.0\n" {
+		t.Errorf("Unexpected hook result %+v", r)
+	}
+	if summary.Count(ToolSucceeded) != 2 || summary.Results[1].HooksRun != 1 {
+		t.Errorf("Unexpected summary %+v", summary.Results)
+	}
+}
+
+func TestApply_RefusesOnDrift(t *testing.T) {
+	logFile := fakeMise(t)
+
+	client := NewClient()
+	plan, err := client.Plan(context.Background(), planConfig(), InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	// Someone installs yq between plan and apply
+	writeLs(t, logFile, `{"yq": [{"version": "4.44.1", "installed": true, "active": true}]}`)
+
+	err = client.Apply(context.Background(), plan)
+	if err == nil || !strings.Contains(err.Error(), "tool yq: absent -> [4.44.1*]") {
+		t.Fatalf("Expected drift error, got: %v", err)
+	}
+	if strings.Contains(strings.Join(miseCalls(t, logFile), "\n"), "install") {
+		t.Error("Expected nothing to be installed after drift")
+	}
+}
+
+func TestPlan_UninstallsDroppedVersions(t *testing.T) {
+	logFile := fakeMise(t)
+	writeLs(t, logFile, `{"python": [
+		{"version": "3.10.4", "installed": true},
+		{"version": "3.11.9", "installed": true, "active": true},
+		{"version": "3.12.1", "installed": true}
+	]}`)
+
+	cfg := &config.Config{Tools: map[string]config.Tool{
+		"python": {Versions: []config.ToolVersion{{Version: "3.11", Default: true}, {Version: "3.12"}}},
+	}}
+	client := NewClient()
+	plan, err := client.Plan(context.Background(), cfg, InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	var uninstalls []string
+	for _, action := range plan.Actions {
+		if action.Type == ActionUninstall {
+			uninstalls = append(uninstalls, action.String())
+		}
+	}
+	if strings.Join(uninstalls, ",") != "uninstall python@3.10.4" {
+		t.Fatalf("Expected only the dropped version to be uninstalled, got %v", plan.Actions)
+	}
+
+	if err := client.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if calls := strings.Join(miseCalls(t, logFile), "\n"); !strings.Contains(calls, "uninstall python@3.10.4") {
+		t.Errorf("Expected apply to run mise uninstall, got:\n%s", calls)
+	}
+}
+
+func TestPlan_UpgradeToPlannedVersion(t *testing.T) {
+	logFile := fakeMise(t)
+	writeLs(t, logFile, `{"jq": [{"version": "1.7.0", "installed": true, "active": true}]}`)
+	outdated := filepath.Join(filepath.Dir(logFile), "outdated.json")
+	if err := os.WriteFile(outdated, []byte(`{"jq": {"requested": "1.7", "current": "1.7.0", "latest": "1.7.1"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Tools: map[string]config.Tool{"jq": {Version: "1.7"}}}
+	client := NewClient()
+	plan, err := client.Plan(context.Background(), cfg, InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan.Actions) != 1 || plan.Actions[0].String() != "upgrade jq 1.7.0 -> 1.7.1" {
+		t.Fatalf("Expected an upgrade to 1.7.1, got %v", plan.Actions)
+	}
+
+	if err := client.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	calls := strings.Join(miseCalls(t, logFile), "\n")
+	for _, want := range []string{"install jq@1.7.1", "use -g jq@1.7.1"} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("Expected mise %q, got:\n%s", want, calls)
+		}
+	}
+	if strings.Contains(calls, "upgrade") {
+		t.Errorf("Expected apply not to run an unpinned mise upgrade, got:\n%s", calls)
+	}
+
+	// Without mise outdated there is no version to plan an upgrade to
+	if err := os.WriteFile(outdated, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Plan(context.Background(), cfg, InstallOptions{StateDir: t.TempDir()}); err == nil {
+		t.Error("Expected plan to fail when mise outdated fails")
+	}
+}
+
+func TestApply_RefusesOnConfigChange(t *testing.T) {
+	fakeMise(t)
+	configFile := filepath.Join(t.TempDir(), "tools.yaml")
+	if err := os.WriteFile(configFile, []byte("tools:\n  jq: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient()
+	plan, err := client.Plan(context.Background(), planConfig(), InstallOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	plan.ConfigPath = configFile
+	if plan.ConfigHash, err = config.HashFile(configFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(configFile, []byte("tools:\n  jq: {}\n  yq: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Apply(context.Background(), plan); err == nil || !strings.Contains(err.Error(), "changed since the plan was made") {
+		t.Errorf("Expected apply to refuse a changed config, got %v", err)
+	}
+}
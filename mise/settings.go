@@ -1,51 +1,71 @@
 package mise
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
+	"os"
 	"strings"
 
 	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/proc"
 )
 
-// ApplyMiseSettings applies mise settings from config
-func (c *Client) ApplyMiseSettings(ctx context.Context, cfg *config.Config) error {
-	if cfg.Settings == nil {
+// Setting is one mise setting (mise settings set <key> <value>)
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ConfiguredSettings returns the mise settings set in config
+func ConfiguredSettings(cfg *config.Config) []Setting {
+	if cfg == nil || cfg.Settings == nil {
 		return nil
 	}
 
-	// Apply npm.package_manager
+	var settings []Setting
 	if cfg.Settings.NPM.PackageManager != "" {
-		key := "npm.package_manager"
-		value := cfg.Settings.NPM.PackageManager
-		if err := c.runMiseSettings(ctx, key, value); err != nil {
-			return fmt.Errorf("failed to set %s: %w", key, err)
-		}
+		settings = append(settings, Setting{Key: "npm.package_manager", Value: cfg.Settings.NPM.PackageManager})
 	}
-
-	// Apply experimental
 	if cfg.Settings.Experimental != "" {
-		key := "experimental"
-		value := cfg.Settings.Experimental
-		if err := c.runMiseSettings(ctx, key, value); err != nil {
-			return fmt.Errorf("failed to set %s: %w", key, err)
-		}
+		settings = append(settings, Setting{Key: "experimental", Value: cfg.Settings.Experimental})
 	}
+	return settings
+}
 
+// ApplyMiseSettings applies mise settings from config
+func (c *Client) ApplyMiseSettings(ctx context.Context, cfg *config.Config) error {
+	for _, setting := range ConfiguredSettings(cfg) {
+		if err := c.runMiseSettings(ctx, setting.Key, setting.Value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", setting.Key, err)
+		}
+	}
 	return nil
 }
 
 // runMiseSettings runs mise settings set command
 func (c *Client) runMiseSettings(ctx context.Context, key, value string) error {
-	cmd := exec.CommandContext(ctx, "mise", "settings", "set", key, value)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("mise settings set failed: %w, output: %s", err, string(output))
+	cmd := proc.Command(ctx, "mise", "settings", "set", key, value)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := runCommand(ctx, cmd); err != nil {
+		return fmt.Errorf("mise settings set failed: %w, output: %s", err, output.String())
 	}
 	return nil
 }
 
+// GetSetting returns the current value of a mise setting (mise settings get)
+func (c *Client) GetSetting(ctx context.Context, key string) (string, error) {
+	cmd := proc.Command(ctx, "mise", "settings", "get", key)
+	cmd.Env = append(os.Environ(), getMiseEnv()...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if err := runCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("mise settings get %s failed: %w", key, err)
+	}
+	return strings.TrimSpace(output.String()), nil
+}
+
 // GetDefaultsHooks returns the default hooks from config
 func GetDefaultsHooks(cfg *config.Config) (preinstall, postinstall []config.Hook) {
 	if cfg == nil || cfg.Defaults == nil {
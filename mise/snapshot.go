@@ -0,0 +1,134 @@
+package mise
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Snapshot is the global mise state before a run: the global config file
+// that mise use -g edits, and the installed tools with their active versions
+type Snapshot struct {
+	ConfigFile string
+	Config     []byte // nil if the file did not exist
+	Mode       os.FileMode
+	Tools      Inventory
+}
+
+// Snapshot records the global mise config file and the tool inventory
+func (c *Client) Snapshot(ctx context.Context) (*Snapshot, error) {
+	s := &Snapshot{ConfigFile: globalConfigFile(), Mode: 0644}
+	data, err := os.ReadFile(s.ConfigFile)
+	switch {
+	case err == nil:
+		s.Config = data
+		if info, err := os.Stat(s.ConfigFile); err == nil {
+			s.Mode = info.Mode().Perm()
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read mise global config: %w", err)
+	}
+
+	inv, err := c.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.Tools = inv
+	return s, nil
+}
+
+// Restore puts back the global mise config file of s, through a temporary
+// file so that an interrupted restore never leaves it truncated, then
+// re-activates with mise use -g any tool whose active version still differs
+// from s.
+// Versions installed since the snapshot stay installed. It returns the
+// reverted tools as "tool from -> to".
+func (c *Client) Restore(ctx context.Context, s *Snapshot) ([]string, error) {
+	if s.Config == nil {
+		if err := os.Remove(s.ConfigFile); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove mise global config: %w", err)
+		}
+	} else if err := writeFileAtomic(s.ConfigFile, s.Config, s.Mode); err != nil {
+		return nil, fmt.Errorf("failed to restore mise global config: %w", err)
+	}
+
+	current, err := c.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(s.Tools))
+	for name := range s.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var reverted []string
+	var failed []string
+	for _, name := range names {
+		before, now := activeVersion(s.Tools[name]), activeVersion(current[name])
+		if before == "" || before == now {
+			continue
+		}
+		if err := c.SetGlobal(ctx, name+"@"+before); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		reverted = append(reverted, fmt.Sprintf("%s %s -> %s", name, orNone(now), before))
+	}
+	if len(failed) > 0 {
+		return reverted, fmt.Errorf("failed to re-activate %d tool(s): %v", len(failed), failed)
+	}
+	return reverted, nil
+}
+
+// activeVersion returns the active version of an inventory entry
+func activeVersion(versions []InstalledVersion) string {
+	for _, v := range versions {
+		if v.Active {
+			return v.Version
+		}
+	}
+	return ""
+}
+
+// orNone returns version, or "none" if it is empty
+func orNone(version string) string {
+	if version == "" {
+		return "none"
+	}
+	return version
+}
+
+// writeFileAtomic writes data to path with perm: a temporary file in the same
+// directory is renamed over path, so readers never see a partial file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,93 @@
+package mise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClient_SnapshotRestore(t *testing.T) {
+	logFile := fakeMise(t)
+	dir := filepath.Dir(logFile)
+	lsFile := filepath.Join(dir, "ls.json")
+	configFile := globalConfigFile()
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := "[tools]\njq = \"1.6\"\n"
+	if err := os.WriteFile(configFile, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lsFile, []byte(`{"jq":[{"version":"1.6","installed":true,"active":true}],"rg":[{"version":"14.0","installed":true,"active":true}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient()
+	snapshot, err := client.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	if string(snapshot.Config) != original || snapshot.Mode != 0600 {
+		t.Errorf("snapshot config = %q mode %v", snapshot.Config, snapshot.Mode)
+	}
+
+	// The run switches jq, adds yq and leaves rg alone
+	if err := os.WriteFile(configFile, []byte("[tools]\njq = \"1.7\"\nyq = \"4\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lsFile, []byte(`{"jq":[{"version":"1.6","installed":true},{"version":"1.7","installed":true,"active":true}],"rg":[{"version":"14.0","installed":true,"active":true}],"yq":[{"version":"4.44","installed":true,"active":true}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(logFile)
+
+	reverted, err := client.Restore(context.Background(), snapshot)
+	if err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	data, _ := os.ReadFile(configFile)
+	if string(data) != original {
+		t.Errorf("config after restore = %q, expected %q", data, original)
+	}
+	if info, err := os.Stat(configFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the restored config to keep mode 0600, got %v, %v", info, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(configFile)); len(entries) != 1 {
+		t.Errorf("Expected no temporary files next to the config, got %v", entries)
+	}
+	if len(reverted) != 1 || reverted[0] != "jq 1.7 -> 1.6" {
+		t.Errorf("reverted = %v, expected jq 1.7 -> 1.6", reverted)
+	}
+	calls := strings.Join(miseCalls(t, logFile), "\n")
+	if !strings.Contains(calls, "use -g jq@1.6") || strings.Contains(calls, "rg@") {
+		t.Errorf("unexpected mise calls:\n%s", calls)
+	}
+}
+
+func TestClient_RestoreRemovesNewConfig(t *testing.T) {
+	fakeMise(t)
+	client := NewClient()
+	snapshot, err := client.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	if snapshot.Config != nil {
+		t.Fatalf("expected no config in snapshot, got %q", snapshot.Config)
+	}
+
+	configFile := globalConfigFile()
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("[tools]\njq = \"1.7\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Restore(context.Background(), snapshot); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Errorf("config created by the run was not removed: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package mise
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ToolStatus is the outcome of a tool in a run
+type ToolStatus string
+
+const (
+	ToolSucceeded ToolStatus = "succeeded"
+	ToolSkipped   ToolStatus = "skipped"
+	ToolFailed    ToolStatus = "failed"
+)
+
+// Exit codes for a run
+const (
+	ExitOK          = 0   // every selected tool succeeded (optional failures are warnings)
+	ExitFailed      = 1   // at least one required tool failed
+	ExitInterrupted = 130 // the run was stopped by SIGINT or SIGTERM
+)
+
+// ToolResult records what happened to one tool
+type ToolResult struct {
+	Tool         string
+	Action       string // install or upgrade
+	Status       ToolStatus
+	Optional     bool
+	Reason       string     // why the tool failed or was skipped
+	ErrorClass   ErrorClass // category of the failure, see ClassifyError
+	HooksRun     int
+	HooksSkipped int
+	Duration     time.Duration
+}
+
+// Summary collects the tool results of a run, in run order
+type Summary struct {
+	Results []*ToolResult
+	// Interrupted is the signal that stopped the run; the results are
+	// partial. Empty for a run that finished.
+	Interrupted string
+}
+
+// add appends a result and returns it
+func (s *Summary) add(result *ToolResult) *ToolResult {
+	s.Results = append(s.Results, result)
+	return result
+}
+
+// Count returns the number of tools with status
+func (s *Summary) Count(status ToolStatus) int {
+	n := 0
+	for _, r := range s.Results {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// RequiredFailures returns the failed tools that are not optional
+func (s *Summary) RequiredFailures() []*ToolResult {
+	var failed []*ToolResult
+	for _, r := range s.Results {
+		if r.Status == ToolFailed && !r.Optional {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// ExitCode returns the process exit code for the run
+func (s *Summary) ExitCode() int {
+	if s.Interrupted != "" {
+		return ExitInterrupted
+	}
+	if len(s.RequiredFailures()) > 0 {
+		return ExitFailed
+	}
+	return ExitOK
+}
+
+// Print writes the summary table
+func (s *Summary) Print(w io.Writer) {
+	if len(s.Results) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n=== Summary ===")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tACTION\tSTATUS\tHOOKS\tREASON")
+	for _, r := range s.Results {
+		status := string(r.Status)
+		if r.Optional && r.Status == ToolFailed {
+			status += " (optional)"
+		}
+		hooks := "-"
+		if r.HooksRun > 0 || r.HooksSkipped > 0 {
+			hooks = fmt.Sprintf("%d run, %d skipped", r.HooksRun, r.HooksSkipped)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Tool, r.Action, status, hooks, firstLine(r.Reason))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "%d succeeded, %d skipped, %d failed\n",
+		s.Count(ToolSucceeded), s.Count(ToolSkipped), s.Count(ToolFailed))
+	if s.Interrupted != "" {
+		fmt.Fprintf(w, "Interrupted by %s; the summary is partial\n", s.Interrupted)
+	}
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package mise
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSummary_ExitCode(t *testing.T) {
+	summary := &Summary{}
+	summary.add(&ToolResult{Tool: "jq", Status: ToolSucceeded})
+	summary.add(&ToolResult{Tool: "gh", Status: ToolFailed, Optional: true})
+	if code := summary.ExitCode(); code != ExitOK {
+		t.Errorf("Expected optional failure to exit %d, got %d", ExitOK, code)
+	}
+
+	summary.add(&ToolResult{Tool: "yq", Status: ToolFailed})
+	if code := summary.ExitCode(); code != ExitFailed {
+		t.Errorf("Expected required failure to exit %d, got %d", ExitFailed, code)
+	}
+}
+
+func TestSummary_Print(t *testing.T) {
+	summary := &Summary{}
+	summary.add(&ToolResult{Tool: "jq", Action: "install", Status: ToolSucceeded, HooksRun: 2, HooksSkipped: 1})
+	summary.add(&ToolResult{Tool: "yq", Action: "install", Status: ToolFailed, Reason: "mise install failed\nmore detail"})
+	summary.add(&ToolResult{Tool: "plugin", Action: "install", Status: ToolSkipped, Reason: "depends on failed tool 'yq'"})
+
+	var buf bytes.Buffer
+	summary.Print(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"2 run, 1 skipped",
+		"mise install failed",
+		"depends on failed tool 'yq'",
+		"1 succeeded, 1 skipped, 1 failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "more detail") {
+		t.Error("Expected only the first line of the reason")
+	}
+}
@@ -0,0 +1,110 @@
+package mise
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/proc"
+)
+
+// LsRemote lists the versions of a tool available remotely (mise ls-remote).
+// Results are cached on the client for the rest of the run.
+func (c *Client) LsRemote(ctx context.Context, tool string) ([]string, error) {
+	if versions, ok := c.remoteVersions[tool]; ok {
+		return versions, nil
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	cmd := proc.Command(ctx, "mise", "ls-remote", tool)
+	cmd.Env = append(os.Environ(), commandEnv()...)
+
+	stdout := new(strings.Builder)
+	stderr := new(strings.Builder)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := runCommand(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("mise ls-remote %s failed: %s", tool, strings.TrimSpace(stderr.String()))
+	}
+
+	versions := parseLsRemote(stdout.String())
+	if c.remoteVersions == nil {
+		c.remoteVersions = make(map[string][]string)
+	}
+	c.remoteVersions[tool] = versions
+	return versions, nil
+}
+
+// parseLsRemote extracts one version per line from mise ls-remote output
+func parseLsRemote(output string) []string {
+	var versions []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		versions = append(versions, fields[0])
+	}
+	return versions
+}
+
+// ResolveVersion turns a configured version into the version handed to mise.
+// An empty version means "latest". Plain versions and aliases are returned
+// unchanged; constraints such as ">=1.20 <1.23" are resolved to the highest
+// matching remote version. Resolutions are cached per client.
+func (c *Client) ResolveVersion(ctx context.Context, tool, version string) (string, error) {
+	if version == "" {
+		return "latest", nil
+	}
+	if !config.IsConstraint(version) {
+		return version, nil
+	}
+
+	key := tool + "@" + version
+	if resolved, ok := c.resolvedVersions[key]; ok {
+		return resolved, nil
+	}
+
+	constraint, err := config.ParseConstraint(version)
+	if err != nil {
+		return "", err
+	}
+
+	candidates, err := c.LsRemote(ctx, tool)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, ok := constraint.Highest(candidates)
+	if !ok {
+		return "", fmt.Errorf("no version of %s matches '%s'", tool, constraint)
+	}
+
+	if c.resolvedVersions == nil {
+		c.resolvedVersions = make(map[string]string)
+	}
+	c.resolvedVersions[key] = resolved
+	return resolved, nil
+}
+
+// outsideConstraint reports whether active, the active version of a tool
+// mise manages, does not satisfy its configured version. Only constraints
+// can be left: mise keeps plain versions, prefixes and aliases itself.
+func outsideConstraint(version, active string) bool {
+	if !config.IsConstraint(version) {
+		return false
+	}
+	constraint, err := config.ParseConstraint(version)
+	if err != nil {
+		return false
+	}
+	return active == "" || !constraint.Check(active)
+}
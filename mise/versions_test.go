@@ -0,0 +1,57 @@
+package mise
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mise-seq/config-loader/config"
+)
+
+func TestParseLsRemote(t *testing.T) {
+	output := "1.20.0\n1.20.1\n\n1.21.0  \n"
+
+	versions := parseLsRemote(output)
+	expected := []string{"1.20.0", "1.20.1", "1.21.0"}
+	if len(versions) != len(expected) {
+		t.Fatalf("Expected %d versions, got %d: %v", len(expected), len(versions), versions)
+	}
+	for i, v := range expected {
+		if versions[i] != v {
+			t.Errorf("versions[%d] = %s, expected %s", i, versions[i], v)
+		}
+	}
+}
+
+func TestResolveVersion_Cached(t *testing.T) {
+	client := NewClient()
+	client.remoteVersions["go"] = []string{"1.20.14", "1.21.13", "1.22.7", "1.23.2"}
+
+	resolved, err := client.ResolveVersion(context.Background(), "go", ">=1.20 <1.23")
+	if err != nil {
+		t.Fatalf("ResolveVersion failed: %v", err)
+	}
+	if resolved != "1.22.7" {
+		t.Errorf("Expected 1.22.7, got %s", resolved)
+	}
+
+	// Plain versions are passed through without consulting mise
+	resolved, err = client.ResolveVersion(context.Background(), "node", "20")
+	if err != nil {
+		t.Fatalf("ResolveVersion failed: %v", err)
+	}
+	if resolved != "20" {
+		t.Errorf("Expected 20, got %s", resolved)
+	}
+}
+
+func TestHookStateKey(t *testing.T) {
+	single := config.Tool{Version: "20"}
+	if key := hookStateKey("node", single, "20"); key != "node" {
+		t.Errorf("Expected node, got %s", key)
+	}
+
+	multi := config.Tool{Versions: []config.ToolVersion{{Version: "18"}, {Version: "20"}}}
+	if key := hookStateKey("node", multi, "18"); key != "node@18" {
+		t.Errorf("Expected node@18, got %s", key)
+	}
+}
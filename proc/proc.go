@@ -0,0 +1,181 @@
+// Package proc runs the mise and hook subprocesses of a run so that stopping
+// the run stops them too. Each command is started in its own process group,
+// so an interrupt reaches every process a hook or mise spawned (e.g. the
+// compiler behind cargo install), not just the direct child:
+//
+//	ctx, stop := proc.NotifyContext(context.Background())
+//	defer stop()
+//	cmd := proc.Command(ctx, "sh", "-c", script)
+//	err := proc.Run(cmd)
+//
+// On the first SIGINT or SIGTERM the context is canceled and the signal is
+// forwarded to the running process groups. Groups still alive after
+// GracePeriod are killed; a second signal kills them at once.
+package proc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// GracePeriod is how long an interrupted command may take to exit before its
+// process group is killed
+var GracePeriod = 10 * time.Second
+
+// Interrupt is the cancellation cause of a run stopped by a signal. It
+// matches context.Canceled.
+type Interrupt struct {
+	Signal os.Signal
+}
+
+func (e *Interrupt) Error() string { return "interrupted by " + e.SignalName() }
+
+// Is makes errors.Is(err, context.Canceled) true for an interrupt
+func (e *Interrupt) Is(target error) bool { return target == context.Canceled }
+
+// Interrupted returns the interrupt that canceled ctx; nil if ctx was not
+// canceled by a signal
+func Interrupted(ctx context.Context) *Interrupt {
+	var interrupt *Interrupt
+	if errors.As(context.Cause(ctx), &interrupt) {
+		return interrupt
+	}
+	return nil
+}
+
+// NotifyContext returns a copy of parent that is canceled with an *Interrupt
+// on the first SIGINT or SIGTERM. Later signals kill the process groups still
+// shutting down. stop releases the signal handler.
+func NotifyContext(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		first := true
+		for {
+			select {
+			case sig := <-signals:
+				if first {
+					first = false
+					cancel(&Interrupt{Signal: sig})
+				} else {
+					killPending()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			cancel(nil)
+		})
+	}
+}
+
+// Command returns exec.CommandContext(ctx, name, args...), set up to run in
+// its own process group. When ctx is canceled, the group receives the signal
+// of the interrupt (SIGTERM otherwise), then SIGKILL after GracePeriod.
+// Run it with Run or Wait, which stop that timer once the command is done.
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setup(ctx, cmd)
+	// Children left holding stdout or stderr must not block Wait
+	cmd.WaitDelay = GracePeriod + time.Second
+	return cmd
+}
+
+// Run starts cmd, a Command, and waits for it (see Wait)
+func Run(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return Wait(cmd)
+}
+
+// Wait waits for cmd, a started Command. Once the command is done, the kill
+// timer of its group is stopped, so that a group id reused later is never
+// killed; processes left in an interrupted group are killed then.
+func Wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	release(cmd.Process.Pid)
+	return err
+}
+
+// pending holds the kill timers of interrupted process groups, by group id
+var pending = struct {
+	sync.Mutex
+	timers map[int]*time.Timer
+}{timers: make(map[int]*time.Timer)}
+
+// escalate kills the process group pgid after GracePeriod, unless release
+// or killPending does so first
+func escalate(pgid int) {
+	pending.Lock()
+	defer pending.Unlock()
+	if _, ok := pending.timers[pgid]; ok {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(GracePeriod, func() {
+		pending.Lock()
+		current := pending.timers[pgid] == timer
+		if current {
+			delete(pending.timers, pgid)
+		}
+		pending.Unlock()
+		if current {
+			killGroup(pgid)
+		}
+	})
+	pending.timers[pgid] = timer
+}
+
+// release stops the kill timer of the group pgid, whose command is done, and
+// kills what is left of the group if it was interrupted
+func release(pgid int) {
+	pending.Lock()
+	timer, ok := pending.timers[pgid]
+	if ok {
+		timer.Stop()
+		delete(pending.timers, pgid)
+	}
+	pending.Unlock()
+	if ok {
+		killGroup(pgid)
+	}
+}
+
+// killPending kills every process group waiting for its grace period
+func killPending() {
+	pending.Lock()
+	defer pending.Unlock()
+	for pgid, timer := range pending.timers {
+		timer.Stop()
+		delete(pending.timers, pgid)
+		killGroup(pgid)
+	}
+}
+
+// SignalName returns the conventional name of the signal, e.g. SIGINT
+func (e *Interrupt) SignalName() string {
+	switch e.Signal {
+	case os.Interrupt:
+		return "SIGINT"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	}
+	return e.Signal.String()
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package proc
+
+import (
+	"context"
+	"os/exec"
+)
+
+// setup keeps the default cancellation: without process groups to signal,
+// the command itself is killed when ctx is canceled
+func setup(ctx context.Context, cmd *exec.Cmd) {}
+
+// killGroup does nothing; escalate is only used on unix
+func killGroup(pgid int) {}
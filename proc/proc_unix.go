@@ -0,0 +1,41 @@
+//go:build unix
+
+package proc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setup starts cmd as the leader of a new process group and signals the whole
+// group when ctx is canceled
+func setup(ctx context.Context, cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		sig := syscall.SIGTERM
+		if interrupt := Interrupted(ctx); interrupt != nil {
+			if s, ok := interrupt.Signal.(syscall.Signal); ok {
+				sig = s
+			}
+		}
+		// The group id is the leader's pid
+		pgid := cmd.Process.Pid
+		if err := syscall.Kill(-pgid, sig); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				return os.ErrProcessDone
+			}
+			return err
+		}
+		escalate(pgid)
+		return nil
+	}
+}
+
+// killGroup sends SIGKILL to the process group pgid
+func killGroup(pgid int) {
+	// The group may be gone already
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+}
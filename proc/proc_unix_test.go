@@ -0,0 +1,137 @@
+//go:build unix
+
+package proc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withGracePeriod shortens GracePeriod for a test
+func withGracePeriod(t *testing.T, d time.Duration) {
+	old := GracePeriod
+	GracePeriod = d
+	t.Cleanup(func() { GracePeriod = old })
+}
+
+func TestCommand_ForwardsInterrupt(t *testing.T) {
+	withGracePeriod(t, 5*time.Second)
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	// The trap only fires if the signal reaches the shell's own group
+	cmd := Command(ctx, "sh", "-c", "trap 'echo got INT; exit 3' INT; echo ready; while :; do sleep 0.05; done")
+	var out syncBuffer
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, &out, "ready")
+	cancel(&Interrupt{Signal: os.Interrupt})
+
+	start := time.Now()
+	err := Wait(cmd)
+	if err == nil || time.Since(start) > 3*time.Second {
+		t.Fatalf("Wait() = %v after %v", err, time.Since(start))
+	}
+	if !strings.Contains(out.String(), "got INT") {
+		t.Errorf("SIGINT was not forwarded, output %q", out.String())
+	}
+	if got := Interrupted(ctx); got == nil || got.SignalName() != "SIGINT" {
+		t.Errorf("Interrupted() = %v", got)
+	}
+	if !errors.Is(context.Cause(ctx), context.Canceled) {
+		t.Error("an interrupt should match context.Canceled")
+	}
+}
+
+func TestCommand_KillsGroupAfterGracePeriod(t *testing.T) {
+	withGracePeriod(t, 200*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Both the shell and its child ignore SIGTERM; the child keeps stdout open
+	cmd := Command(ctx, "sh", "-c", "trap '' TERM; sleep 30 & echo ready; wait")
+	var out syncBuffer
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, &out, "ready")
+	cancel()
+
+	start := time.Now()
+	if err := Wait(cmd); err == nil {
+		t.Fatal("expected the killed command to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("process group was not killed, Wait took %v", elapsed)
+	}
+}
+
+func TestRun_StopsKillTimerOfExitedGroup(t *testing.T) {
+	withGracePeriod(t, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The shell exits at once on SIGTERM, well within the grace period
+	cmd := Command(ctx, "sh", "-c", "trap 'exit 3' TERM; echo ready; while :; do sleep 0.05; done")
+	var out syncBuffer
+	cmd.Stdout = &out
+	done := make(chan error, 1)
+	go func() { done <- Run(cmd) }()
+	waitFor(t, &out, "ready")
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the interrupted command to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not exit on SIGTERM")
+	}
+	if n := pendingTimers(); n != 0 {
+		t.Errorf("expected no pending kill timer after the group exited, got %d", n)
+	}
+}
+
+// pendingTimers returns the number of groups waiting for their grace period
+func pendingTimers() int {
+	pending.Lock()
+	defer pending.Unlock()
+	return len(pending.timers)
+}
+
+// waitFor waits until out contains s
+func waitFor(t *testing.T, out *syncBuffer, s string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), s) {
+		if time.Now().After(deadline) {
+			t.Fatalf("command did not print %q", s)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while the command writes it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mise-seq/config-loader/mise"
+)
+
+// JUnit XML elements, as read by GitHub Actions test reporters, GitLab and Jenkins
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Props     []junitProperty `xml:"properties>property,omitempty"`
+	Cases     []junitCase     `xml:"testcase"`
+	SystemErr *junitText      `xml:"system-err,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut *junitText    `xml:"system-out,omitempty"`
+	SystemErr *junitText    `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",cdata"`
+}
+
+// junitText is captured output, kept readable as CDATA
+type junitText struct {
+	Text string `xml:",cdata"`
+}
+
+// newJUnitText returns nil for empty output, leaving the element out
+func newJUnitText(s string) *junitText {
+	if s == "" {
+		return nil
+	}
+	return &junitText{Text: xmlSafe(s)}
+}
+
+// ansiEscape matches terminal color and cursor sequences in captured output
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// xmlSafe removes terminal escapes and characters XML 1.0 does not allow
+func xmlSafe(s string) string {
+	s = ansiEscape.ReplaceAllString(s, "")
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xFFFE && r != 0xFFFF && r != utf8.RuneError) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// writeJUnit writes the report as JUnit XML: one test suite for the run and
+// one test case per tool, with the tool's install and hooks as steps in the
+// captured output. Failed optional tools are reported as skipped, matching
+// the exit code.
+func (r *Report) writeJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:      "mise-seq " + r.Command,
+		Time:      seconds(r.DurationMs),
+		Timestamp: r.StartedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	for _, p := range []junitProperty{
+		{"config", r.Config},
+		{"platform", r.Platform},
+		{"exit_code", fmt.Sprint(r.ExitCode)},
+	} {
+		if p.Value != "" {
+			suite.Props = append(suite.Props, p)
+		}
+	}
+
+	for _, t := range r.Tools {
+		c := junitCase{
+			Name:      t.Name,
+			Classname: "mise-seq." + r.Command,
+			Time:      seconds(t.DurationMs),
+		}
+		out, errOut := junitOutput(t)
+		c.SystemOut, c.SystemErr = newJUnitText(out), newJUnitText(errOut)
+
+		switch t.Status {
+		case string(mise.ToolFailed):
+			msg := &junitMessage{Message: xmlSafe(firstLine(t.Reason)), Type: t.ErrorClass, Body: xmlSafe(t.Reason)}
+			if t.Optional {
+				msg.Message = "optional tool failed: " + msg.Message
+				c.Skipped = msg
+				suite.Skipped++
+			} else {
+				c.Failure = msg
+				suite.Failures++
+			}
+		case string(mise.ToolSkipped), StatusExcluded:
+			c.Skipped = &junitMessage{Message: xmlSafe(t.Reason)}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Tests = len(suite.Cases)
+
+	// Errors that stopped the run before or outside any tool
+	if len(r.Errors) > 0 && suite.Failures == 0 && !r.Success {
+		suite.Errors = 1
+		suite.SystemErr = newJUnitText(strings.Join(r.Errors, "\n"))
+	}
+
+	doc := junitSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitOutput renders a tool's steps as system-out and system-err: a header
+// line per step followed by its captured output
+func junitOutput(t *Tool) (string, string) {
+	var out, errOut strings.Builder
+	for _, s := range t.steps() {
+		header := fmt.Sprintf("[%s] %s: %s (%ss)\n", s.Kind, s.Name, s.status(), seconds(s.DurationMs))
+		out.WriteString(header)
+		out.WriteString(withNewline(s.Stdout))
+		if s.Stderr != "" || s.Error != "" {
+			errOut.WriteString(header)
+			errOut.WriteString(withNewline(s.Stderr))
+			if s.Error != "" {
+				errOut.WriteString(withNewline(s.Error))
+			}
+		}
+	}
+	return out.String(), errOut.String()
+}
+
+// withNewline terminates non-empty s with a newline
+func withNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
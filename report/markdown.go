@@ -0,0 +1,133 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mise-seq/config-loader/mise"
+)
+
+// writeMarkdown writes the report as Markdown suitable for a CI job summary
+// (e.g. $GITHUB_STEP_SUMMARY): a result line, a table of tools, and a
+// collapsible section with the steps of each tool that ran. Sections of
+// failed tools are expanded.
+func (r *Report) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	result := "succeeded"
+	if !r.Success {
+		result = "failed"
+	}
+	fmt.Fprintf(&b, "## mise-seq %s %s\n\n", r.Command, result)
+
+	var meta []string
+	if r.Config != "" {
+		meta = append(meta, "config `"+r.Config+"`")
+	}
+	if r.Platform != "" {
+		meta = append(meta, "platform `"+r.Platform+"`")
+	}
+	meta = append(meta, fmt.Sprintf("exit code %d", r.ExitCode), "took "+seconds(r.DurationMs)+"s")
+	b.WriteString(strings.Join(meta, ", ") + "\n\n")
+
+	counts := make(map[string]int)
+	for _, t := range r.Tools {
+		counts[t.Status]++
+	}
+	if counts[string(mise.ToolSucceeded)]+counts[string(mise.ToolFailed)]+counts[string(mise.ToolSkipped)] > 0 {
+		fmt.Fprintf(&b, "**%d succeeded, %d skipped, %d failed**\n\n",
+			counts[string(mise.ToolSucceeded)], counts[string(mise.ToolSkipped)], counts[string(mise.ToolFailed)])
+	}
+
+	for _, e := range r.Errors {
+		fmt.Fprintf(&b, "> **Error:** %s\n\n", markdownCell(e))
+	}
+
+	if len(r.Tools) > 0 {
+		b.WriteString("| Tool | Version | Action | Status | Duration | Details |\n")
+		b.WriteString("|------|---------|--------|--------|----------|---------|\n")
+		for _, t := range r.Tools {
+			version := t.Version
+			if t.InstalledVersion != "" {
+				version += " (installed " + t.InstalledVersion + ")"
+			}
+			status := t.Status
+			if status == string(mise.ToolFailed) && t.Optional {
+				status += " (optional)"
+			}
+			details := t.Reason
+			if t.ErrorClass != "" {
+				details = "`" + t.ErrorClass + "` " + firstLine(details)
+			}
+			duration := ""
+			if t.DurationMs > 0 {
+				duration = seconds(t.DurationMs) + "s"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				markdownCell(t.Name), markdownCell(version), markdownCell(t.Action),
+				markdownCell(status), duration, markdownCell(details))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, t := range r.Tools {
+		steps := t.steps()
+		if len(steps) == 0 {
+			continue
+		}
+		open := ""
+		if t.Status == string(mise.ToolFailed) {
+			open = " open"
+		}
+		fmt.Fprintf(&b, "<details%s>\n<summary>%s: %s</summary>\n\n", open, t.Name, t.Status)
+		for _, s := range steps {
+			fmt.Fprintf(&b, "- **%s** `%s`: %s (%ss)\n", s.Kind, markdownCell(s.Name), s.status(), seconds(s.DurationMs))
+			output := withNewline(s.Stdout) + withNewline(s.Stderr)
+			if s.Error != "" && !strings.Contains(output, s.Error) {
+				output += withNewline(s.Error)
+			}
+			output = ansiEscape.ReplaceAllString(output, "")
+			if output != "" {
+				fence := codeFence(output)
+				fmt.Fprintf(&b, "\n  %s\n%s  %s\n\n", fence, indent(output, "  "), fence)
+			}
+		}
+		b.WriteString("\n</details>\n\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell makes s safe for a single table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in s
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// indent prefixes every line of s
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		if line != "" {
+			b.WriteString(prefix + line)
+		}
+	}
+	return b.String()
+}
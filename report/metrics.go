@@ -0,0 +1,245 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/mise"
+)
+
+// metricLastSuccess is carried over from the previous metrics file, so it
+// keeps the time of the last successful run after a failed one
+const metricLastSuccess = "mise_seq_last_success_timestamp_seconds"
+
+// toolStatuses are the values of the status label of mise_seq_tool_status
+var toolStatuses = []string{
+	string(mise.ToolSucceeded),
+	string(mise.ToolFailed),
+	string(mise.ToolSkipped),
+	StatusExcluded,
+}
+
+// AddInventory records which configured tools mise has installed, and the
+// active version
+func (r *Report) AddInventory(inv mise.Inventory) {
+	for _, t := range r.Tools {
+		if t.Status == StatusExcluded {
+			continue
+		}
+		installed := inv.Manages(t.Name)
+		t.Installed = &installed
+		t.InstalledVersion = inv.Active(t.Name)
+	}
+}
+
+// WriteMetrics writes the report as Prometheus text-format metrics, for the
+// node_exporter textfile collector. lastSuccess holds the last successful
+// run of each command; the report's own command is updated if it succeeded.
+func (r *Report) WriteMetrics(w io.Writer, lastSuccess map[string]float64) error {
+	m := &metricsWriter{w: bufio.NewWriter(w)}
+	cmd := label("command", r.Command)
+
+	m.family("mise_seq_run_success", "Whether the last run succeeded (1) or failed (0).")
+	m.sample("mise_seq_run_success", boolValue(r.Success), cmd)
+	m.family("mise_seq_run_exit_code", "Exit code of the last run.")
+	m.sample("mise_seq_run_exit_code", float64(r.ExitCode), cmd)
+	m.family("mise_seq_run_duration_seconds", "Duration of the last run.")
+	m.sample("mise_seq_run_duration_seconds", float64(r.DurationMs)/1000, cmd)
+	m.family("mise_seq_run_timestamp_seconds", "Time the last run finished.")
+	m.sample("mise_seq_run_timestamp_seconds", unixSeconds(r.FinishedAt), cmd)
+
+	last := make(map[string]float64, len(lastSuccess)+1)
+	for command, ts := range lastSuccess {
+		last[command] = ts
+	}
+	if r.Success {
+		last[r.Command] = unixSeconds(r.FinishedAt)
+	}
+	commands := make([]string, 0, len(last))
+	for command := range last {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	m.family(metricLastSuccess, "Time the last successful run finished.")
+	for _, command := range commands {
+		m.sample(metricLastSuccess, last[command], label("command", command))
+	}
+
+	m.family("mise_seq_tool_status", "Outcome of each tool in the last run (1 for the current status).")
+	for _, t := range r.Tools {
+		if t.Status == "" {
+			continue
+		}
+		for _, status := range toolStatuses {
+			m.sample("mise_seq_tool_status", boolValue(t.Status == status), label("tool", t.Name), label("status", status))
+		}
+	}
+
+	m.family("mise_seq_tool_installed", "Whether mise has the tool installed.")
+	for _, t := range r.Tools {
+		if t.Installed != nil {
+			m.sample("mise_seq_tool_installed", boolValue(*t.Installed), label("tool", t.Name))
+		}
+	}
+
+	m.family("mise_seq_tool_info", "Configured and installed version of each tool.")
+	for _, t := range r.Tools {
+		if t.Status == StatusExcluded {
+			continue
+		}
+		m.sample("mise_seq_tool_info", 1, label("tool", t.Name), label("version", t.Version), label("installed_version", t.InstalledVersion))
+	}
+
+	m.family("mise_seq_tool_duration_seconds", "Time spent on each tool in the last run.")
+	for _, t := range r.Tools {
+		if t.Status != "" && t.Status != StatusExcluded {
+			m.sample("mise_seq_tool_duration_seconds", float64(t.DurationMs)/1000, label("tool", t.Name))
+		}
+	}
+
+	m.family("mise_seq_tool_hook_failures", "Hooks of each tool that failed in the last run.")
+	for _, t := range r.Tools {
+		if t.Status == "" || t.Status == StatusExcluded {
+			continue
+		}
+		failures := 0
+		for _, h := range t.Hooks {
+			if h.Error != "" {
+				failures++
+			}
+		}
+		m.sample("mise_seq_tool_hook_failures", float64(failures), label("tool", t.Name))
+	}
+
+	if m.err != nil {
+		return m.err
+	}
+	return m.w.Flush()
+}
+
+// WriteMetricsFile writes the metrics to path atomically: a temporary file in
+// the same directory is renamed over path, so a scrape never reads a partial
+// file. The last successful run times of the previous file are kept.
+func (r *Report) WriteMetricsFile(path string) error {
+	lastSuccess, err := readLastSuccess(path)
+	if err != nil {
+		return fmt.Errorf("failed to read metrics file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	// The textfile collector only reads *.prom files, so it ignores this one
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.WriteMetrics(tmp, lastSuccess); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// readLastSuccess reads the last successful run time of each command from an
+// existing metrics file
+func readLastSuccess(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	last := make(map[string]float64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		rest, ok := strings.CutPrefix(scanner.Text(), metricLastSuccess+`{command="`)
+		if !ok {
+			continue
+		}
+		command, value, ok := strings.Cut(rest, `"} `)
+		if !ok {
+			continue
+		}
+		if ts, err := strconv.ParseFloat(value, 64); err == nil {
+			last[command] = ts
+		}
+	}
+	return last, scanner.Err()
+}
+
+// metricsWriter writes Prometheus text format, keeping the first error
+type metricsWriter struct {
+	w      *bufio.Writer
+	header [2]string // name and help of a family without samples yet
+	err    error
+}
+
+// family starts a gauge; its HELP and TYPE lines are written with the first
+// sample, so families without samples are left out
+func (m *metricsWriter) family(name, help string) {
+	m.header = [2]string{name, help}
+}
+
+// sample writes one sample
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	if m.header[0] == name {
+		m.printf("# HELP %s %s\n# TYPE %s gauge\n", name, m.header[1], name)
+		m.header = [2]string{}
+	}
+	m.printf("%s{%s} %s\n", name, strings.Join(labels, ","), strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (m *metricsWriter) printf(format string, args ...any) {
+	if m.err == nil {
+		_, m.err = fmt.Fprintf(m.w, format, args...)
+	}
+}
+
+// labelEscaper escapes label values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label formats a name="value" label pair
+func label(name, value string) string {
+	return name + `="` + labelEscaper.Replace(value) + `"`
+}
+
+// boolValue is 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// unixSeconds returns t as fractional Unix seconds, with millisecond precision
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
@@ -0,0 +1,425 @@
+// Package report builds machine-readable reports of mise-seq runs
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/mise"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the report schema. It is bumped on any
+// incompatible change; new optional fields do not bump it.
+const SchemaVersion = 1
+
+// Format is an output format for reports
+type Format string
+
+const (
+	FormatTable    Format = "table" // human-readable text (no report)
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatJUnit    Format = "junit"    // JUnit XML, one test case per tool
+	FormatMarkdown Format = "markdown" // Markdown, e.g. for a CI job summary
+)
+
+// ParseFormat parses an --output value
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatTable, FormatJSON, FormatYAML:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("unknown output format '%s' (expected table, json or yaml)", s)
+}
+
+// Target is a report file written at the end of a run
+type Target struct {
+	Format Format
+	Path   string
+}
+
+// ParseTarget parses a --report value of the form format=path
+func ParseTarget(s string) (Target, error) {
+	name, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return Target{}, fmt.Errorf("invalid report '%s' (expected format=path, e.g. junit=report.xml)", s)
+	}
+	switch f := Format(name); f {
+	case FormatJSON, FormatYAML, FormatJUnit, FormatMarkdown:
+		return Target{Format: f, Path: path}, nil
+	}
+	return Target{}, fmt.Errorf("unknown report format '%s' (expected json, yaml, junit or markdown)", name)
+}
+
+// String returns the target in --report form
+func (t Target) String() string {
+	return string(t.Format) + "=" + t.Path
+}
+
+// Report is the result of one mise-seq command
+type Report struct {
+	SchemaVersion int       `json:"schema_version" yaml:"schema_version"`
+	Command       string    `json:"command" yaml:"command"`
+	RunID         string    `json:"run_id,omitempty" yaml:"run_id,omitempty"`
+	Config        string    `json:"config,omitempty" yaml:"config,omitempty"`
+	Platform      string    `json:"platform,omitempty" yaml:"platform,omitempty"`
+	StateDir      string    `json:"state_dir,omitempty" yaml:"state_dir,omitempty"`
+	StartedAt     time.Time `json:"started_at" yaml:"started_at"`
+	FinishedAt    time.Time `json:"finished_at" yaml:"finished_at"`
+	DurationMs    int64     `json:"duration_ms" yaml:"duration_ms"`
+	Success       bool      `json:"success" yaml:"success"`
+	ExitCode      int       `json:"exit_code" yaml:"exit_code"`
+	Interrupted   string    `json:"interrupted,omitempty" yaml:"interrupted,omitempty"` // signal that stopped the run
+	Restored      bool      `json:"restored,omitempty" yaml:"restored,omitempty"`
+	Tools         []*Tool   `json:"tools" yaml:"tools"`
+	Errors        []string  `json:"errors,omitempty" yaml:"errors,omitempty"`
+
+	index map[string]*Tool
+}
+
+// Tool is one configured tool and what happened to it
+type Tool struct {
+	Name             string    `json:"name" yaml:"name"`
+	Version          string    `json:"version,omitempty" yaml:"version,omitempty"`
+	Versions         []string  `json:"versions,omitempty" yaml:"versions,omitempty"`
+	Optional         bool      `json:"optional,omitempty" yaml:"optional,omitempty"`
+	Tags             []string  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Depends          []string  `json:"depends,omitempty" yaml:"depends,omitempty"`
+	Installed        *bool     `json:"installed,omitempty" yaml:"installed,omitempty"`
+	InstalledVersion string    `json:"installed_version,omitempty" yaml:"installed_version,omitempty"`
+	Action           string    `json:"action,omitempty" yaml:"action,omitempty"`
+	Status           string    `json:"status,omitempty" yaml:"status,omitempty"`
+	Reason           string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Error            string    `json:"error,omitempty" yaml:"error,omitempty"`
+	ErrorClass       string    `json:"error_class,omitempty" yaml:"error_class,omitempty"`
+	DurationMs       int64     `json:"duration_ms,omitempty" yaml:"duration_ms,omitempty"`
+	Commands         []Command `json:"commands,omitempty" yaml:"commands,omitempty"`
+	Hooks            []Hook    `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+}
+
+// Command is a mise command run for a tool
+type Command struct {
+	Args       []string  `json:"args" yaml:"args"`
+	StartedAt  time.Time `json:"started_at,omitzero" yaml:"started_at,omitempty"`
+	ExitCode   int       `json:"exit_code" yaml:"exit_code"`
+	DurationMs int64     `json:"duration_ms" yaml:"duration_ms"`
+	Stdout     string    `json:"stdout,omitempty" yaml:"stdout,omitempty"`
+	Stderr     string    `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+	Error      string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Hook is a hook run (or skipped) for a tool
+type Hook struct {
+	Type        string    `json:"type" yaml:"type"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Script      string    `json:"script" yaml:"script"`
+	StartedAt   time.Time `json:"started_at,omitzero" yaml:"started_at,omitempty"`
+	Status      string    `json:"status,omitempty" yaml:"status,omitempty"` // succeeded, failed, timed_out or skipped
+	Skipped     bool      `json:"skipped" yaml:"skipped"`
+	SkipReason  string    `json:"skip_reason,omitempty" yaml:"skip_reason,omitempty"` // state unchanged, or the guard that skipped it
+	ExitCode    int       `json:"exit_code" yaml:"exit_code"`
+	Attempts    int       `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	DurationMs  int64     `json:"duration_ms" yaml:"duration_ms"`
+	Stdout      string    `json:"stdout,omitempty" yaml:"stdout,omitempty"`
+	Stderr      string    `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+	Error       string    `json:"error,omitempty" yaml:"error,omitempty"`
+	// ContinuedOnError is set for a failure that continue_on_error tolerated
+	ContinuedOnError bool `json:"continued_on_error,omitempty" yaml:"continued_on_error,omitempty"`
+}
+
+// Tool status values besides the mise.ToolStatus ones
+const (
+	StatusExcluded = "excluded"
+)
+
+// New starts a report for command
+func New(command string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Command:       command,
+		StartedAt:     time.Now().UTC(),
+		Tools:         []*Tool{},
+		index:         make(map[string]*Tool),
+	}
+}
+
+// Tool returns the entry for name, adding it if needed
+func (r *Report) Tool(name string) *Tool {
+	if t, ok := r.index[name]; ok {
+		return t
+	}
+	t := &Tool{Name: name}
+	r.index[name] = t
+	r.Tools = append(r.Tools, t)
+	return t
+}
+
+// AddConfig adds the configured tools of cfg, in config order for tools not
+// yet in the report, and fills in their configured versions
+func (r *Report) AddConfig(cfg *config.Config) {
+	tools := config.GetTools(cfg)
+
+	names := append([]string(nil), config.GetToolOrder(cfg)...)
+	var rest []string
+	for name := range tools {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	names = append(names, rest...)
+
+	for _, name := range names {
+		tool, ok := tools[name]
+		if !ok {
+			continue
+		}
+		t := r.Tool(name)
+		t.Version = string(tool.DefaultVersion())
+		if t.Version == "" {
+			t.Version = "latest"
+		}
+		if tool.HasMultipleVersions() {
+			t.Versions = nil
+			for _, v := range tool.InstallVersions() {
+				t.Versions = append(t.Versions, string(v.Version))
+			}
+		}
+		t.Optional = tool.Optional
+		t.Tags = tool.Tags
+		t.Depends = tool.Depends
+	}
+}
+
+// AddExcluded adds tools left out of the run, with the reason
+func (r *Report) AddExcluded(excluded []config.Exclusion) {
+	for _, ex := range excluded {
+		t := r.Tool(ex.Tool)
+		t.Status = StatusExcluded
+		t.Reason = ex.Reason
+	}
+}
+
+// OnEvent records installer events; a Report is a mise.Observer
+func (r *Report) OnEvent(e mise.Event) {
+	if e.Tool == "" {
+		return
+	}
+	t := r.Tool(e.Tool)
+
+	switch e.Type {
+	case mise.EventToolStarted:
+		t.Action = e.Action
+	case mise.EventCommandFinished:
+		cmd := Command{Args: e.Command, StartedAt: startedAt(e), DurationMs: e.Duration.Milliseconds()}
+		if e.Result != nil {
+			cmd.ExitCode = e.Result.ExitCode
+			cmd.Stdout = e.Result.Stdout
+			cmd.Stderr = e.Result.Stderr
+		}
+		if e.Err != nil {
+			cmd.Error = e.Err.Error()
+		}
+		t.Commands = append(t.Commands, cmd)
+	case mise.EventHookFinished:
+		hook := Hook{Type: string(e.HookType), Description: e.Description, Script: e.Script, StartedAt: startedAt(e)}
+		if hr := e.HookResult; hr != nil {
+			hook.Status = string(hr.Status)
+			hook.Skipped = hr.Skipped
+			hook.SkipReason = hr.SkipReason
+			hook.ExitCode = hr.ExitCode
+			hook.Attempts = hr.Attempts
+			hook.DurationMs = hr.Duration.Milliseconds()
+			hook.Stdout = hr.Stdout
+			hook.Stderr = hr.Stderr
+			hook.ContinuedOnError = hr.ContinuedOnError
+			if hr.ContinuedOnError {
+				hook.Error = hr.Error.Error()
+			}
+		}
+		if e.Err != nil {
+			hook.Error = e.Err.Error()
+		}
+		t.Hooks = append(t.Hooks, hook)
+	case mise.EventToolFailed:
+		if e.Err != nil {
+			t.Error = e.Err.Error()
+		}
+	}
+}
+
+// startedAt returns when the step of a finished event started; zero if the
+// event has no time
+func startedAt(e mise.Event) time.Time {
+	if e.Time.IsZero() {
+		return time.Time{}
+	}
+	return e.Time.Add(-e.Duration).UTC()
+}
+
+// ApplySummary records the outcome of each tool of an install or upgrade
+func (r *Report) ApplySummary(summary *mise.Summary) {
+	if summary == nil {
+		return
+	}
+	r.Interrupted = summary.Interrupted
+	for _, res := range summary.Results {
+		t := r.Tool(res.Tool)
+		if res.Action != "-" {
+			t.Action = res.Action
+		}
+		t.Status = string(res.Status)
+		t.Reason = res.Reason
+		t.ErrorClass = string(res.ErrorClass)
+		t.Optional = res.Optional
+		t.DurationMs = res.Duration.Milliseconds()
+	}
+}
+
+// Finish records the end of the run
+func (r *Report) Finish(err error, exitCode int) {
+	r.FinishedAt = time.Now().UTC()
+	r.DurationMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+	r.ExitCode = exitCode
+	r.Success = err == nil && exitCode == 0
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+}
+
+// Write encodes the report in format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatJUnit:
+		return r.writeJUnit(w)
+	case FormatMarkdown:
+		return r.writeMarkdown(w)
+	}
+	return fmt.Errorf("report format '%s' is not machine-readable", format)
+}
+
+// WriteFile writes the report to target.Path, creating its directory
+func (r *Report) WriteFile(target Target) error {
+	if dir := filepath.Dir(target.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	f, err := os.Create(target.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if err := r.Write(f, target.Format); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s report: %w", target.Format, err)
+	}
+	return f.Close()
+}
+
+// steps returns a tool's commands and hooks in run order, by start time.
+// For a tool with several versions the hooks and commands of each version
+// interleave. Steps without a start time are ordered by phase: preinstall
+// hooks, mise commands, then postinstall hooks.
+func (t *Tool) steps() []step {
+	var steps []step
+	for _, phase := range []string{string(hooks.HookTypePreinstall), "", string(hooks.HookTypePostinstall)} {
+		if phase == "" {
+			for _, c := range t.Commands {
+				kind := "mise"
+				if len(c.Args) > 0 {
+					kind = c.Args[0]
+				}
+				steps = append(steps, step{
+					Name:       strings.Join(append([]string{"mise"}, c.Args...), " "),
+					Kind:       kind,
+					StartedAt:  c.StartedAt,
+					ExitCode:   c.ExitCode,
+					DurationMs: c.DurationMs,
+					Stdout:     c.Stdout,
+					Stderr:     c.Stderr,
+					Error:      c.Error,
+				})
+			}
+			continue
+		}
+		for _, h := range t.Hooks {
+			if h.Type != phase {
+				continue
+			}
+			name := h.Description
+			if name == "" {
+				name = firstLine(h.Script)
+			}
+			steps = append(steps, step{
+				Name:       name,
+				Kind:       h.Type,
+				StartedAt:  h.StartedAt,
+				Skipped:    h.Skipped,
+				SkipReason: h.SkipReason,
+				ExitCode:   h.ExitCode,
+				DurationMs: h.DurationMs,
+				Stdout:     h.Stdout,
+				Stderr:     h.Stderr,
+				Error:      h.Error,
+			})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].StartedAt.Before(steps[j].StartedAt) })
+	return steps
+}
+
+// step is one mise command or hook of a tool, for the JUnit and Markdown reports
+type step struct {
+	Name       string
+	Kind       string // install, upgrade, use, preinstall or postinstall
+	StartedAt  time.Time
+	Skipped    bool
+	SkipReason string
+	ExitCode   int
+	DurationMs int64
+	Stdout     string
+	Stderr     string
+	Error      string
+}
+
+// status describes the outcome of a step in a few words
+func (s step) status() string {
+	switch {
+	case s.Skipped && s.SkipReason != "":
+		return "skipped (" + s.SkipReason + ")"
+	case s.Skipped:
+		return "skipped"
+	case s.Error != "" || s.ExitCode != 0:
+		return fmt.Sprintf("failed (exit %d)", s.ExitCode)
+	}
+	return "ok"
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// seconds formats milliseconds as seconds
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
@@ -0,0 +1,362 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/mise"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Format
+		wantErr  bool
+	}{
+		{"", "", true},
+		{"table", FormatTable, false},
+		{"json", FormatJSON, false},
+		{"yaml", FormatYAML, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseFormat(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReport_EventsAndSummary(t *testing.T) {
+	cfg := &config.Config{
+		ToolsOrder: []string{"jq", "rg"},
+		Tools: map[string]config.Tool{
+			"jq": {Version: "1.7", Tags: []string{"cli"}},
+			"rg": {Optional: true},
+		},
+	}
+
+	r := New("install")
+	r.OnEvent(mise.Event{Type: mise.EventToolStarted, Tool: "jq", Action: "install"})
+	r.OnEvent(mise.Event{
+		Type:    mise.EventCommandFinished,
+		Tool:    "jq",
+		Command: []string{"mise", "install", "jq@1.7"},
+		Result:  &mise.Result{ExitCode: 0, Stdout: "ok"},
+	})
+	r.OnEvent(mise.Event{
+		Type:       mise.EventHookFinished,
+		Tool:       "jq",
+		HookType:   hooks.HookTypePostinstall,
+		Script:     "jq --version",
+		HookResult: &hooks.HookResult{ExitCode: 0, Duration: 20 * time.Millisecond},
+	})
+	r.OnEvent(mise.Event{Type: mise.EventToolFailed, Tool: "rg", Err: errors.New("boom")})
+
+	summary := &mise.Summary{Results: []*mise.ToolResult{
+		{Tool: "jq", Action: "install", Status: mise.ToolSucceeded},
+		{Tool: "rg", Action: "install", Status: mise.ToolFailed, Optional: true, Reason: "boom"},
+	}}
+	r.ApplySummary(summary)
+	r.AddConfig(cfg)
+	r.AddExcluded([]config.Exclusion{{Tool: "mas", Reason: "requires os darwin"}})
+	r.Finish(nil, summary.ExitCode())
+
+	if len(r.Tools) != 3 {
+		t.Fatalf("Expected 3 tools, got %d", len(r.Tools))
+	}
+	jq := r.Tool("jq")
+	if jq.Version != "1.7" || jq.Status != "succeeded" || len(jq.Commands) != 1 || len(jq.Hooks) != 1 {
+		t.Errorf("Unexpected jq entry: %+v", jq)
+	}
+	if jq.Hooks[0].DurationMs != 20 {
+		t.Errorf("Expected hook duration 20ms, got %d", jq.Hooks[0].DurationMs)
+	}
+	rg := r.Tool("rg")
+	if rg.Version != "latest" || rg.Error != "boom" || !rg.Optional {
+		t.Errorf("Unexpected rg entry: %+v", rg)
+	}
+	if mas := r.Tool("mas"); mas.Status != StatusExcluded {
+		t.Errorf("Expected mas to be excluded, got %+v", mas)
+	}
+	if !r.Success || r.ExitCode != 0 {
+		t.Errorf("Expected a successful run, got success=%v exit=%d", r.Success, r.ExitCode)
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	r := New("status")
+	r.Tool("jq").InstalledVersion = "1.7.1"
+	r.Finish(errors.New("config file not found: tools.yaml"), 1)
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, FormatJSON); err != nil {
+		t.Fatalf("Write(json) error: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if decoded["schema_version"] != float64(SchemaVersion) || decoded["success"] != false {
+		t.Errorf("Unexpected report: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := r.Write(&buf, FormatYAML); err != nil {
+		t.Fatalf("Write(yaml) error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "schema_version: 1") || !strings.Contains(buf.String(), "installed_version: 1.7.1") {
+		t.Errorf("Unexpected YAML report:\n%s", buf.String())
+	}
+
+	if err := r.Write(&buf, FormatTable); err == nil {
+		t.Error("Expected an error writing a table report")
+	}
+}
+
+// ciReport builds an install report with a succeeded, a failed and an excluded tool
+func ciReport() *Report {
+	r := New("install")
+	r.OnEvent(mise.Event{
+		Type:    mise.EventCommandFinished,
+		Tool:    "jq",
+		Command: []string{"install", "jq@1.7"},
+		Result:  &mise.Result{Stdout: "mise jq@1.7 installed"},
+	})
+	r.OnEvent(mise.Event{
+		Type:       mise.EventHookFinished,
+		Tool:       "jq",
+		HookType:   hooks.HookTypePostinstall,
+		Script:     "jq --version",
+		HookResult: &hooks.HookResult{Stdout: "\x1b[32mjq-1.7\x1b[0m\x00", Duration: 15 * time.Millisecond},
+	})
+	r.OnEvent(mise.Event{
+		Type:       mise.EventHookFinished,
+		Tool:       "node",
+		HookType:   hooks.HookTypePostinstall,
+		Script:     "npm i -g pnpm",
+		HookResult: &hooks.HookResult{ExitCode: 1, Stderr: "EACCES | denied"},
+		Err:        errors.New("hook failed with exit code 1"),
+	})
+	r.ApplySummary(&mise.Summary{Results: []*mise.ToolResult{
+		{Tool: "jq", Action: "install", Status: mise.ToolSucceeded, Duration: 1500 * time.Millisecond},
+		{Tool: "node", Action: "install", Status: mise.ToolFailed, Reason: "postinstall hook failed for node: exit 1", ErrorClass: mise.ErrorHook},
+	}})
+	r.AddExcluded([]config.Exclusion{{Tool: "mas", Reason: "requires os darwin"}})
+	r.Finish(errors.New("installation failed: node"), 1)
+	return r
+}
+
+func TestReport_WriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ciReport().Write(&buf, FormatJUnit); err != nil {
+		t.Fatalf("Write(junit) error: %v", err)
+	}
+
+	var doc junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Report is not valid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 1 || len(doc.Suites) != 1 {
+		t.Fatalf("Unexpected counts: %+v", doc)
+	}
+
+	cases := doc.Suites[0].Cases
+	if cases[0].Name != "jq" || cases[0].Time != "1.500" || cases[0].Failure != nil {
+		t.Errorf("Unexpected jq case: %+v", cases[0])
+	}
+	if !strings.Contains(cases[0].SystemOut.Text, "[install] mise install jq@1.7: ok") || !strings.Contains(cases[0].SystemOut.Text, "jq-1.7") {
+		t.Errorf("Expected steps in system-out, got:\n%s", cases[0].SystemOut.Text)
+	}
+	if f := cases[1].Failure; f == nil || f.Type != "hook_failed" {
+		t.Errorf("Expected a classified failure for node, got %+v", cases[1])
+	}
+	if !strings.Contains(cases[1].SystemErr.Text, "EACCES") {
+		t.Errorf("Expected hook stderr in system-err, got:\n%s", cases[1].SystemErr.Text)
+	}
+	if cases[2].Skipped == nil || cases[2].Skipped.Message != "requires os darwin" {
+		t.Errorf("Expected mas to be skipped, got %+v", cases[2])
+	}
+}
+
+func TestReport_WriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ciReport().Write(&buf, FormatMarkdown); err != nil {
+		t.Fatalf("Write(markdown) error: %v", err)
+	}
+	md := buf.String()
+
+	for _, want := range []string{
+		"## mise-seq install failed",
+		"**1 succeeded, 0 skipped, 1 failed**",
+		"| node |  | install | failed |  | `hook_failed` postinstall hook failed for node: exit 1 |",
+		"<details open>\n<summary>node: failed</summary>",
+		"EACCES | denied",
+		"- **postinstall** `jq --version`: ok (0.015s)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in markdown:\n%s", want, md)
+		}
+	}
+}
+
+func TestReport_StepsMultiVersion(t *testing.T) {
+	r := New("install")
+	start := time.Now()
+	at := func(n int) time.Time { return start.Add(time.Duration(n) * time.Second) }
+	for _, v := range []struct {
+		version string
+		offset  int
+	}{{"3.11", 0}, {"3.12", 10}} {
+		r.OnEvent(mise.Event{Type: mise.EventHookFinished, Tool: "python", HookType: hooks.HookTypePreinstall,
+			Script: "echo pre " + v.version, Time: at(v.offset + 1), Duration: time.Second, HookResult: &hooks.HookResult{}})
+		r.OnEvent(mise.Event{Type: mise.EventCommandFinished, Tool: "python",
+			Command: []string{"install", "python@" + v.version}, Time: at(v.offset + 3), Duration: 2 * time.Second})
+		r.OnEvent(mise.Event{Type: mise.EventHookFinished, Tool: "python", HookType: hooks.HookTypePostinstall,
+			Script: "echo post " + v.version, Time: at(v.offset + 4), Duration: time.Second, HookResult: &hooks.HookResult{}})
+	}
+
+	var names []string
+	for _, s := range r.Tool("python").steps() {
+		names = append(names, s.Name)
+	}
+	expected := []string{
+		"echo pre 3.11", "mise install python@3.11", "echo post 3.11",
+		"echo pre 3.12", "mise install python@3.12", "echo post 3.12",
+	}
+	if strings.Join(names, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected steps in run order:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(names, "\n"))
+	}
+
+	if got := r.Tool("python").Commands[0].StartedAt; !got.Equal(at(1)) {
+		t.Errorf("Expected the install of 3.11 to start at %v, got %v", at(1), got)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, FormatMarkdown); err != nil {
+		t.Fatalf("Write(markdown) error: %v", err)
+	}
+	md := buf.String()
+	if i, j := strings.Index(md, "echo post 3.11"), strings.Index(md, "echo pre 3.12"); i < 0 || j < 0 || i > j {
+		t.Errorf("Expected 3.11 steps before 3.12 steps in markdown:\n%s", md)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("junit=out/report.xml")
+	if err != nil || target.Format != FormatJUnit || target.Path != "out/report.xml" {
+		t.Errorf("ParseTarget() = %+v, %v", target, err)
+	}
+	for _, bad := range []string{"junit", "junit=", "table=x", "html=x"} {
+		if _, err := ParseTarget(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestReport_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "summary.md")
+	if err := ciReport().WriteFile(Target{Format: FormatMarkdown, Path: path}); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "## mise-seq install") {
+		t.Errorf("Unexpected report file: %q, %v", data, err)
+	}
+}
+
+func TestReport_WriteMetrics(t *testing.T) {
+	r := ciReport()
+	r.AddInventory(mise.Inventory{"jq": {{Version: "1.7.1", Installed: true, Active: true}}})
+
+	var buf bytes.Buffer
+	if err := r.WriteMetrics(&buf, map[string]float64{"install": 1700000000, "upgrade": 1690000000}); err != nil {
+		t.Fatalf("WriteMetrics error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE mise_seq_run_success gauge\n",
+		`mise_seq_run_success{command="install"} 0` + "\n",
+		`mise_seq_run_exit_code{command="install"} 1` + "\n",
+		// A failed run keeps the previous success times
+		`mise_seq_last_success_timestamp_seconds{command="install"} 1700000000` + "\n",
+		`mise_seq_last_success_timestamp_seconds{command="upgrade"} 1690000000` + "\n",
+		`mise_seq_tool_status{tool="jq",status="succeeded"} 1` + "\n",
+		`mise_seq_tool_status{tool="jq",status="failed"} 0` + "\n",
+		`mise_seq_tool_status{tool="node",status="failed"} 1` + "\n",
+		`mise_seq_tool_status{tool="mas",status="excluded"} 1` + "\n",
+		`mise_seq_tool_installed{tool="jq"} 1` + "\n",
+		`mise_seq_tool_installed{tool="node"} 0` + "\n",
+		`mise_seq_tool_info{tool="jq",version="",installed_version="1.7.1"} 1` + "\n",
+		`mise_seq_tool_duration_seconds{tool="jq"} 1.5` + "\n",
+		`mise_seq_tool_hook_failures{tool="jq"} 0` + "\n",
+		`mise_seq_tool_hook_failures{tool="node"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `tool="mas",version`) || strings.Contains(out, `mise_seq_tool_installed{tool="mas"}`) {
+		t.Errorf("excluded tool has version metrics:\n%s", out)
+	}
+	if strings.Count(out, "# TYPE mise_seq_last_success_timestamp_seconds") != 1 {
+		t.Errorf("expected one TYPE line per metric:\n%s", out)
+	}
+}
+
+func TestReport_WriteMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "textfile", "mise_seq.prom")
+
+	ok := New("install")
+	ok.Finish(nil, 0)
+	if err := ok.WriteMetricsFile(path); err != nil {
+		t.Fatalf("WriteMetricsFile error: %v", err)
+	}
+	success := fmt.Sprintf(`mise_seq_last_success_timestamp_seconds{command="install"} %s`,
+		strconv.FormatFloat(float64(ok.FinishedAt.UnixMilli())/1000, 'f', -1, 64))
+
+	failed := New("install")
+	failed.Finish(errors.New("installation failed"), 1)
+	if err := failed.WriteMetricsFile(path); err != nil {
+		t.Fatalf("WriteMetricsFile error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("metrics file not written: %v", err)
+	}
+	if !strings.Contains(string(data), success+"\n") || !strings.Contains(string(data), `mise_seq_run_success{command="install"} 0`) {
+		t.Errorf("expected the failed run with the earlier success time %q:\n%s", success, data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("metrics file mode = %v, %v; expected 0644", info.Mode().Perm(), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestLabel_Escaping(t *testing.T) {
+	if got := label("tool", "a\"b\\c\nd"); got != `tool="a\"b\\c\nd"` {
+		t.Errorf("label = %s", got)
+	}
+}
@@ -0,0 +1,374 @@
+// Package runlog keeps the output of every mise command and hook of a
+// mise-seq run on disk, one file per step:
+//
+//	<log-dir>/<run-id>/<tool>/<phase>-<n>.log
+//
+// Each file starts with a header (command line, environment changes, timing
+// and exit code) followed by the captured stdout and stderr.
+package runlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/mise"
+)
+
+// Header keys of a log file
+const (
+	headerCommand  = "command"
+	headerEnv      = "env"
+	headerStarted  = "started"
+	headerDuration = "duration"
+	headerExitCode = "exit code"
+	headerSkipped  = "skipped"
+	headerError    = "error"
+)
+
+// timeLayout is the layout of the started header
+const timeLayout = time.RFC3339Nano
+
+// Capture writes the steps of one run to its directory. It is a mise.Observer.
+type Capture struct {
+	dir     string
+	counts  map[string]int // per tool and phase
+	secrets []string       // of the inherited environment
+	masker  *strings.Replacer
+	err     error
+}
+
+// NewRunID returns a run id that sorts by start time
+func NewRunID(t time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// Start creates the directory of run runID in logDir
+func Start(logDir, runID string) (*Capture, error) {
+	dir := filepath.Join(logDir, runID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	secrets := config.SecretValues(os.Environ())
+	return &Capture{dir: dir, counts: make(map[string]int), secrets: secrets, masker: config.NewMasker(secrets)}, nil
+}
+
+// Dir returns the run directory
+func (c *Capture) Dir() string {
+	return c.dir
+}
+
+// Err returns the first error writing a log file, if any
+func (c *Capture) Err() error {
+	return c.err
+}
+
+// OnEvent writes a log file for each finished mise command and hook
+func (c *Capture) OnEvent(e mise.Event) {
+	switch e.Type {
+	case mise.EventCommandFinished:
+		phase := "mise"
+		if len(e.Command) > 0 {
+			phase = e.Command[0]
+		}
+		header := [][2]string{{headerCommand, "mise " + strings.Join(e.Command, " ")}}
+		var stdout, stderr string
+		exitCode := unknownExitCode(e.Err)
+		if e.Result != nil {
+			stdout, stderr, exitCode = e.Result.Stdout, e.Result.Stderr, e.Result.ExitCode
+		}
+		c.write(e, phase, header, exitCode, false, stdout, stderr)
+	case mise.EventHookFinished:
+		header := [][2]string{{headerCommand, hookCommand(e)}}
+		if e.Description != "" {
+			header = append(header, [2]string{"description", e.Description})
+		}
+		if r := e.HookResult; r != nil && r.SkipReason != "" {
+			header = append(header, [2]string{"skip reason", r.SkipReason})
+		}
+		var stdout, stderr string
+		exitCode, skipped := unknownExitCode(e.Err), false
+		if r := e.HookResult; r != nil {
+			stdout, stderr, exitCode, skipped = r.Stdout, r.Stderr, r.ExitCode, r.Skipped
+		}
+		c.write(e, string(e.HookType), header, exitCode, skipped, stdout, stderr)
+	}
+}
+
+// hookCommand returns the command line of a hook: sh -c "<script>" for an
+// inline hook, otherwise the interpreter run on the script file
+func hookCommand(e mise.Event) string {
+	r := e.HookResult
+	if r == nil || len(r.Command) == 0 || (len(r.Command) == 3 && r.Command[0] == "sh" && r.Command[1] == "-c") {
+		return "sh -c " + strconv.Quote(e.Script)
+	}
+	return strings.Join(r.Command, " ")
+}
+
+// unknownExitCode is the exit code logged for a step without a result:
+// 0 if it succeeded, -1 if it failed
+func unknownExitCode(err error) int {
+	if err != nil {
+		return -1
+	}
+	return 0
+}
+
+// write writes one step's log file
+func (c *Capture) write(e mise.Event, phase string, header [][2]string, exitCode int, skipped bool, stdout, stderr string) {
+	if e.Tool == "" {
+		return
+	}
+	toolDir := filepath.Join(c.dir, ToolDirName(e.Tool))
+	if err := os.MkdirAll(toolDir, 0755); err != nil {
+		c.fail(err)
+		return
+	}
+	key := e.Tool + "/" + phase
+	c.counts[key]++
+	path := filepath.Join(toolDir, fmt.Sprintf("%s-%d.log", phase, c.counts[key]))
+
+	finished := e.Time
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	header = append(header,
+		[2]string{headerEnv, strings.Join(envDiff(e.Env), " ")},
+		[2]string{headerStarted, finished.Add(-e.Duration).UTC().Format(timeLayout)},
+		[2]string{headerDuration, e.Duration.Round(time.Millisecond).String()},
+		[2]string{headerExitCode, strconv.Itoa(exitCode)},
+	)
+	if skipped {
+		header = append(header, [2]string{headerSkipped, "true"})
+	}
+	if e.Err != nil {
+		header = append(header, [2]string{headerError, firstLine(e.Err.Error())})
+	}
+
+	var b strings.Builder
+	for _, h := range header {
+		fmt.Fprintf(&b, "# %s: %s\n", h[0], h[1])
+	}
+	fmt.Fprintf(&b, "\n--- stdout ---\n%s", withNewline(stdout))
+	fmt.Fprintf(&b, "--- stderr ---\n%s", withNewline(stderr))
+
+	// Secrets set by the step's env blocks are masked along with the inherited ones
+	masker := c.masker
+	if secrets := config.SecretValues(e.Env); len(secrets) > 0 {
+		masker = config.NewMasker(append(secrets, c.secrets...))
+	}
+	if err := os.WriteFile(path, []byte(masker.Replace(b.String())), 0644); err != nil {
+		c.fail(err)
+	}
+}
+
+// fail records the first write error
+func (c *Capture) fail(err error) {
+	if c.err == nil {
+		c.err = fmt.Errorf("failed to write log: %w", err)
+	}
+}
+
+// envDiff returns the variables of env whose value differs from the
+// inherited environment; write masks the secret ones
+func envDiff(env []string) []string {
+	var diff []string
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if current, ok := os.LookupEnv(name); ok && current == value {
+			continue
+		}
+		diff = append(diff, name+"="+strconv.Quote(value))
+	}
+	return diff
+}
+
+// unsafeName matches characters replaced in tool directory names
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// ToolDirName returns the directory name of a tool's logs; backend prefixes
+// and paths such as "ubi:owner/repo" become "ubi_owner_repo"
+func ToolDirName(tool string) string {
+	return unsafeName.ReplaceAllString(tool, "_")
+}
+
+// Runs returns the run ids in logDir, oldest first
+func Runs(logDir string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var runs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			runs = append(runs, entry.Name())
+		}
+	}
+	sort.Strings(runs)
+	return runs, nil
+}
+
+// Latest returns the directory of the most recent run in logDir
+func Latest(logDir string) (string, error) {
+	runs, err := Runs(logDir)
+	if err != nil {
+		return "", err
+	}
+	if len(runs) == 0 {
+		return "", fmt.Errorf("no logs in %s", logDir)
+	}
+	return filepath.Join(logDir, runs[len(runs)-1]), nil
+}
+
+// Retention says which runs to keep: the newest Runs runs, and runs younger
+// than MaxAge. Zero values do not limit.
+type Retention struct {
+	Runs   int
+	MaxAge time.Duration
+}
+
+// ParseRetention parses a --log-retention value: a number of runs ("10") or
+// a maximum age ("72h", "7d")
+func ParseRetention(s string) (Retention, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return Retention{Runs: n}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return Retention{MaxAge: time.Duration(n) * 24 * time.Hour}, nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return Retention{MaxAge: d}, nil
+	}
+	return Retention{}, fmt.Errorf("invalid log retention '%s' (expected a number of runs or an age such as 72h or 7d)", s)
+}
+
+// Prune removes the runs in logDir that retention does not keep, and
+// returns how many were removed
+func Prune(logDir string, retention Retention) (int, error) {
+	runs, err := Runs(logDir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i, run := range runs {
+		keep := true
+		if retention.Runs > 0 && i < len(runs)-retention.Runs {
+			keep = false
+		}
+		if retention.MaxAge > 0 {
+			if info, err := os.Stat(filepath.Join(logDir, run)); err == nil && time.Since(info.ModTime()) > retention.MaxAge {
+				keep = false
+			}
+		}
+		if keep {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(logDir, run)); err != nil {
+			return removed, fmt.Errorf("failed to remove old logs: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Entry is one log file of a run
+type Entry struct {
+	Tool     string // tool directory name
+	Phase    string
+	Path     string
+	Command  string
+	Started  time.Time
+	Duration string
+	ExitCode int
+	Skipped  bool
+}
+
+// Entries returns the log files of a run in the order they ran, optionally
+// only those of one tool
+func Entries(runDir, tool string) ([]Entry, error) {
+	pattern := filepath.Join(runDir, "*", "*.log")
+	if tool != "" {
+		pattern = filepath.Join(runDir, ToolDirName(tool), "*.log")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(paths))
+	for _, path := range paths {
+		entry, err := readEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Started.Before(entries[j].Started) })
+	return entries, nil
+}
+
+// readEntry reads the header of a log file
+func readEntry(path string) (Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer f.Close()
+
+	phase, _, _ := strings.Cut(strings.TrimSuffix(filepath.Base(path), ".log"), "-")
+	entry := Entry{Tool: filepath.Base(filepath.Dir(path)), Phase: phase, Path: path, ExitCode: -1}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "# ")
+		if !ok {
+			break
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case headerCommand:
+			entry.Command = value
+		case headerStarted:
+			entry.Started, _ = time.Parse(timeLayout, value)
+		case headerDuration:
+			entry.Duration = value
+		case headerExitCode:
+			entry.ExitCode, _ = strconv.Atoi(value)
+		case headerSkipped:
+			entry.Skipped = value == "true"
+		}
+	}
+	return entry, scanner.Err()
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// withNewline terminates non-empty s with a newline
+func withNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
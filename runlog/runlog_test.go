@@ -0,0 +1,208 @@
+package runlog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mise-seq/config-loader/config"
+	"github.com/mise-seq/config-loader/hooks"
+	"github.com/mise-seq/config-loader/mise"
+	"github.com/mise-seq/config-loader/report"
+)
+
+func TestCapture(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "secret-token-value")
+	logDir := t.TempDir()
+
+	capture, err := Start(logDir, NewRunID(time.Now()))
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	start := time.Now()
+	capture.OnEvent(mise.Event{
+		Type:     mise.EventCommandFinished,
+		Time:     start.Add(3 * time.Second),
+		Tool:     "ubi:owner/repo",
+		Command:  []string{"install", "ubi:owner/repo@1.0"},
+		Env:      []string{"MISE_QUIET=1", "GITHUB_TOKEN=secret-token-value", "DB_PASSWORD=env-block-password"},
+		Result:   &mise.Result{Stdout: "installed with secret-token-value", Stderr: "warning for env-block-password"},
+		Duration: 2 * time.Second,
+	})
+	capture.OnEvent(mise.Event{
+		Type:        mise.EventHookFinished,
+		Time:        start.Add(4 * time.Second),
+		Tool:        "ubi:owner/repo",
+		HookType:    hooks.HookTypePostinstall,
+		Script:      "repo --version",
+		Description: "Verify",
+		HookResult:  &hooks.HookResult{ExitCode: 1, Stderr: "boom"},
+		Duration:    time.Second,
+		Err:         errors.New("hook failed with exit code 1: boom"),
+	})
+	capture.OnEvent(mise.Event{
+		Type:     mise.EventHookFinished,
+		Time:     start.Add(time.Second),
+		Tool:     "ubi:owner/repo",
+		HookType: hooks.HookTypePreinstall,
+		Script:   "true",
+		Duration: time.Second,
+	})
+	if err := capture.Err(); err != nil {
+		t.Fatalf("Capture error: %v", err)
+	}
+
+	installLog := filepath.Join(capture.Dir(), "ubi_owner_repo", "install-1.log")
+	data, err := os.ReadFile(installLog)
+	if err != nil {
+		t.Fatalf("Failed to read install log: %v", err)
+	}
+	text := string(data)
+	for _, want := range []string{
+		"# command: mise install ubi:owner/repo@1.0\n",
+		`GITHUB_TOKEN="[MASKED]" DB_PASSWORD="[MASKED]"`,
+		"# duration: 2s\n",
+		"# exit code: 0\n",
+		"--- stdout ---\ninstalled with [MASKED]\n--- stderr ---\nwarning for [MASKED]\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in install log:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "secret-token-value") || strings.Contains(text, "env-block-password") {
+		t.Errorf("Token leaked into log:\n%s", text)
+	}
+
+	latest, err := Latest(logDir)
+	if err != nil || latest != capture.Dir() {
+		t.Fatalf("Latest() = %s, %v; expected %s", latest, err, capture.Dir())
+	}
+	entries, err := Entries(latest, "ubi:owner/repo")
+	if err != nil {
+		t.Fatalf("Entries() error: %v", err)
+	}
+	var phases []string
+	for _, e := range entries {
+		phases = append(phases, e.Phase)
+	}
+	if strings.Join(phases, ",") != "preinstall,install,postinstall" {
+		t.Errorf("Expected entries in run order, got %v", phases)
+	}
+	if entries[2].ExitCode != 1 || entries[2].Command != `sh -c "repo --version"` {
+		t.Errorf("Unexpected hook entry %+v", entries[2])
+	}
+}
+
+func TestPrune(t *testing.T) {
+	logDir := t.TempDir()
+	for _, run := range []string{"20260101T000000Z-a", "20260102T000000Z-b", "20260103T000000Z-c"} {
+		if err := os.MkdirAll(filepath.Join(logDir, run), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := filepath.Join(logDir, "20260103T000000Z-c")
+	if err := os.Chtimes(old, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(logDir, Retention{Runs: 2})
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune(2 runs) = %d, %v", removed, err)
+	}
+	removed, err = Prune(logDir, Retention{MaxAge: 24 * time.Hour})
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune(24h) = %d, %v", removed, err)
+	}
+	runs, _ := Runs(logDir)
+	if len(runs) != 1 || runs[0] != "20260102T000000Z-b" {
+		t.Errorf("Unexpected runs left: %v", runs)
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Retention
+		wantErr  bool
+	}{
+		{"10", Retention{Runs: 10}, false},
+		{"0", Retention{}, false},
+		{"72h", Retention{MaxAge: 72 * time.Hour}, false},
+		{"7d", Retention{MaxAge: 7 * 24 * time.Hour}, false},
+		{"-1", Retention{}, true},
+		{"forever", Retention{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRetention(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.expected {
+				t.Errorf("ParseRetention(%q) = %+v, %v", tt.input, got, err)
+			}
+		})
+	}
+}
+
+func TestInstaller_MasksHookSecrets(t *testing.T) {
+	const secret = "ghp-secret-token-value"
+	t.Setenv("DEPLOY_TOKEN", secret)
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "mise"), []byte("#!/bin/sh\necho '{}'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var logs strings.Builder
+	logFile := filepath.Join(t.TempDir(), "mise-seq.log")
+	if err := config.InitLoggerWithOptions(config.LoggerOptions{Output: &logs, File: logFile, Mask: config.SecretValues(os.Environ())}); err != nil {
+		t.Fatal(err)
+	}
+	defer config.InitLogger(false)
+
+	capture, err := Start(t.TempDir(), NewRunID(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := report.New("install")
+	cfg := &config.Config{Tools: map[string]config.Tool{
+		"jq": {Preinstall: []config.Hook{{Run: `echo "using $DEPLOY_TOKEN"; echo "denied for $DEPLOY_TOKEN" >&2; exit 1`}}},
+	}}
+	installer := mise.NewInstaller(mise.NewClient(), mise.WithStateDir(t.TempDir()), mise.WithObserver(capture), mise.WithObserver(rep))
+	summary, err := installer.Install(context.Background(), cfg)
+	if err == nil {
+		t.Fatal("Expected the failing hook to fail the install")
+	}
+	config.Error("%v", err)
+	rep.ApplySummary(summary)
+	rep.Finish(err, mise.ExitFailed)
+
+	outputs := map[string]string{"error": err.Error(), "summary": summary.Results[0].Reason, "console log": logs.String()}
+	for _, format := range []report.Format{report.FormatJSON, report.FormatJUnit, report.FormatMarkdown} {
+		var b strings.Builder
+		if werr := rep.Write(&b, format); werr != nil {
+			t.Fatal(werr)
+		}
+		outputs[string(format)+" report"] = b.String()
+	}
+	data, _ := os.ReadFile(logFile)
+	outputs["log file"] = string(data)
+	entries, _ := filepath.Glob(filepath.Join(capture.Dir(), "*", "*.log"))
+	for _, entry := range entries {
+		data, _ := os.ReadFile(entry)
+		outputs[entry] = string(data)
+	}
+
+	if !strings.Contains(outputs["json report"], "denied for "+config.Masked) {
+		t.Errorf("Expected the masked hook stderr in the report, got:\n%s", outputs["json report"])
+	}
+	for name, output := range outputs {
+		if strings.Contains(output, secret) {
+			t.Errorf("%s leaks the token:\n%s", name, output)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a trace export format
+type Format string
+
+const (
+	FormatChrome Format = "chrome" // Chrome trace-event JSON
+	FormatOTLP   Format = "otlp"   // OTLP-JSON (ExportTraceServiceRequest)
+)
+
+// Target is where a trace is exported: a file, or for OTLP also a collector
+// URL (http:// or https://)
+type Target struct {
+	Format Format
+	Path   string
+}
+
+// ParseTarget parses a --trace value: format=path or otlp=http://host:4318
+func ParseTarget(s string) (Target, error) {
+	name, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return Target{}, fmt.Errorf("invalid trace '%s' (expected format=path, e.g. chrome=trace.json)", s)
+	}
+	switch f := Format(name); f {
+	case FormatChrome, FormatOTLP:
+		t := Target{Format: f, Path: path}
+		if t.isURL() && f != FormatOTLP {
+			return Target{}, fmt.Errorf("only otlp traces can be sent to a URL")
+		}
+		return t, nil
+	}
+	return Target{}, fmt.Errorf("unknown trace format '%s' (expected chrome or otlp)", name)
+}
+
+// String returns the target in --trace form
+func (t Target) String() string {
+	return string(t.Format) + "=" + t.Path
+}
+
+// isURL reports whether the target is a collector endpoint
+func (t Target) isURL() bool {
+	return strings.HasPrefix(t.Path, "http://") || strings.HasPrefix(t.Path, "https://")
+}
+
+// Export writes the trace to target, or posts it to a collector
+func (r *Recorder) Export(ctx context.Context, target Target) error {
+	var buf bytes.Buffer
+	var err error
+	switch target.Format {
+	case FormatChrome:
+		err = r.WriteChrome(&buf)
+	case FormatOTLP:
+		err = r.WriteOTLP(&buf)
+	default:
+		err = fmt.Errorf("unknown trace format '%s'", target.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if target.isURL() {
+		return postOTLP(ctx, target.Path, buf.Bytes())
+	}
+	if dir := filepath.Dir(target.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create trace directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(target.Path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
+	}
+	return nil
+}
+
+// postOTLP sends an OTLP-JSON request to a collector. A URL without a path
+// gets the standard /v1/traces.
+func postOTLP(ctx context.Context, url string, body []byte) error {
+	if rest := url[strings.Index(url, "//")+2:]; !strings.Contains(rest, "/") {
+		url += "/v1/traces"
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid trace endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send trace: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to send trace: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// chromeEvent is a complete ("X") event of the Chrome trace-event format
+type chromeEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat"`
+	Phase string         `json:"ph"`
+	TS    int64          `json:"ts"`  // microseconds
+	Dur   int64          `json:"dur"` // microseconds
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// WriteChrome writes the trace as Chrome trace-event JSON. All spans are on
+// one thread; nesting follows from their times.
+func (r *Recorder) WriteChrome(w io.Writer) error {
+	events := []chromeEvent{}
+	for _, s := range r.Spans() {
+		args := make(map[string]any, len(s.Attrs)+1)
+		for _, a := range s.Attrs {
+			args[a.Key] = a.Value
+		}
+		if s.Error != "" {
+			args["error"] = s.Error
+		}
+		cat, _, _ := strings.Cut(s.Name, " ")
+		events = append(events, chromeEvent{
+			Name:  s.Name,
+			Cat:   cat,
+			Phase: "X",
+			TS:    s.Start.UnixMicro(),
+			Dur:   s.End.Sub(s.Start).Microseconds(),
+			PID:   1,
+			TID:   1,
+			Args:  args,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+}
+
+// OTLP-JSON types, following the protobuf JSON mapping of
+// opentelemetry.proto.collector.trace.v1.ExportTraceServiceRequest
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []otlpAttr `json:"attributes,omitempty"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 0 unset, 1 ok, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpSpanKindInternal is SPAN_KIND_INTERNAL
+const otlpSpanKindInternal = 1
+
+// WriteOTLP writes the trace as an OTLP-JSON export request
+func (r *Recorder) WriteOTLP(w io.Writer) error {
+	spans := []otlpSpan{}
+	for _, s := range r.Spans() {
+		span := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+		for _, a := range s.Attrs {
+			span.Attributes = append(span.Attributes, otlpAttribute(a.Key, a.Value))
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.Error}
+		}
+		spans = append(spans, span)
+	}
+
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttr{otlpAttribute("service.name", "mise-seq")}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "mise-seq"}, Spans: spans}},
+	}}}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(req)
+}
+
+// otlpAttribute converts an attribute to an OTLP AnyValue
+func otlpAttribute(key string, value any) otlpAttr {
+	var v map[string]any
+	switch x := value.(type) {
+	case bool:
+		v = map[string]any{"boolValue": x}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(x)}
+	case int64:
+		v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+	case float64:
+		v = map[string]any{"doubleValue": x}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(x)}
+	}
+	return otlpAttr{Key: key, Value: v}
+}
@@ -0,0 +1,167 @@
+// Package tracing records timing spans of a mise-seq run (bootstrap, config
+// load, mise subprocesses, hooks) and exports them as Chrome trace-event JSON
+// (Perfetto, chrome://tracing) or OTLP-JSON (OpenTelemetry collectors, Jaeger).
+//
+// Spans are started from a context carrying a Recorder. Without one, Start
+// returns a nil span whose methods do nothing, so instrumented code does not
+// need to check whether tracing is on.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Attr is a span attribute. Values are strings, bools, ints or float64s.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// Span is a timed operation
+type Span struct {
+	recorder *Recorder
+	TraceID  string // 32 hex digits
+	SpanID   string // 16 hex digits
+	ParentID string // empty for the root span
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Attrs    []Attr
+	Error    string // set if the operation failed
+}
+
+// SetAttr sets an attribute, replacing one with the same key
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	for i := range s.Attrs {
+		if s.Attrs[i].Key == key {
+			s.Attrs[i].Value = value
+			return
+		}
+	}
+	s.Attrs = append(s.Attrs, Attr{key, value})
+}
+
+// Finish ends the span, recording err as its error
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	if s.End.IsZero() {
+		s.End = time.Now()
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// Recorder collects the spans of one trace
+type Recorder struct {
+	mu      sync.Mutex
+	traceID string
+	spans   []*Span
+}
+
+// NewRecorder creates a recorder for a new trace
+func NewRecorder() *Recorder {
+	return &Recorder{traceID: randomHex(16)}
+}
+
+// Spans returns the recorded spans in start order. Spans not finished yet
+// end now.
+func (r *Recorder) Spans() []*Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	spans := make([]*Span, len(r.spans))
+	for i, s := range r.spans {
+		span := *s
+		if span.End.IsZero() {
+			span.End = now
+		}
+		span.Attrs = append([]Attr(nil), s.Attrs...)
+		spans[i] = &span
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+	return spans
+}
+
+// contextKey is the context key of the recorder and current span
+type contextKey struct{}
+
+// spanContext is stored in contexts by WithRecorder and Start
+type spanContext struct {
+	recorder *Recorder
+	span     *Span
+}
+
+// WithRecorder returns a context recording spans started from it in r
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, contextKey{}, spanContext{recorder: r})
+}
+
+// Start starts a span as a child of the current span of ctx, and returns a
+// context with the new span as current. Without a recorder in ctx it returns
+// ctx and a nil span.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	sc, ok := ctx.Value(contextKey{}).(spanContext)
+	if !ok || sc.recorder == nil {
+		return ctx, nil
+	}
+	span := sc.recorder.add(sc.span, name, time.Now(), time.Time{}, attrs)
+	return context.WithValue(ctx, contextKey{}, spanContext{recorder: sc.recorder, span: span}), span
+}
+
+// Record adds a finished span that ran from start for d, as a child of the
+// current span of ctx. It is used for operations timed elsewhere, such as
+// hooks.
+func Record(ctx context.Context, name string, start time.Time, d time.Duration, err error, attrs ...Attr) {
+	sc, ok := ctx.Value(contextKey{}).(spanContext)
+	if !ok || sc.recorder == nil {
+		return
+	}
+	span := sc.recorder.add(sc.span, name, start, start.Add(d), attrs)
+	span.Finish(err)
+}
+
+// add creates a span; a zero end means it is still running
+func (r *Recorder) add(parent *Span, name string, start, end time.Time, attrs []Attr) *Span {
+	span := &Span{
+		recorder: r,
+		TraceID:  r.traceID,
+		SpanID:   randomHex(8),
+		Name:     name,
+		Start:    start,
+		End:      end,
+		Attrs:    append([]Attr(nil), attrs...),
+	}
+	if parent != nil {
+		span.ParentID = parent.SpanID
+	}
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return span
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to time
+		return fmt.Sprintf("%0*x", 2*n, time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
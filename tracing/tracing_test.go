@@ -0,0 +1,227 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleTrace records a run span with a tool span, a hook and a failed
+// command under it
+func sampleTrace() *Recorder {
+	rec := NewRecorder()
+	ctx := WithRecorder(context.Background(), rec)
+
+	ctx, run := Start(ctx, "mise-seq install")
+	toolCtx, tool := Start(ctx, "tool jq", Attr{"tool", "jq"})
+	Record(toolCtx, "hook preinstall", time.Now(), 5*time.Millisecond, nil, Attr{"exit_code", 0})
+	_, cmd := Start(toolCtx, "mise install", Attr{"command", "mise install jq@1.7"})
+	cmd.SetAttr("exit_code", 1)
+	cmd.Finish(errors.New("mise install failed"))
+	tool.Finish(nil)
+	run.Finish(nil)
+	return rec
+}
+
+func TestStart_NoRecorder(t *testing.T) {
+	ctx := context.Background()
+	got, span := Start(ctx, "noop")
+	if span != nil || got != ctx {
+		t.Fatalf("Start without recorder = %v, %v; expected ctx, nil", got, span)
+	}
+	// Methods of the nil span do nothing
+	span.SetAttr("key", "value")
+	span.Finish(errors.New("ignored"))
+	Record(ctx, "noop", time.Now(), time.Second, nil)
+}
+
+func TestRecorder_Spans(t *testing.T) {
+	spans := sampleTrace().Spans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+
+	byName := make(map[string]*Span)
+	for _, s := range spans {
+		byName[s.Name] = s
+		if s.TraceID != spans[0].TraceID || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("span %q has trace %q span %q", s.Name, s.TraceID, s.SpanID)
+		}
+		if s.End.Before(s.Start) {
+			t.Errorf("span %q ends before it starts", s.Name)
+		}
+	}
+
+	root, tool := byName["mise-seq install"], byName["tool jq"]
+	if root.ParentID != "" {
+		t.Errorf("root span has parent %q", root.ParentID)
+	}
+	if tool.ParentID != root.SpanID {
+		t.Errorf("tool span parent = %q, expected %q", tool.ParentID, root.SpanID)
+	}
+	for _, name := range []string{"hook preinstall", "mise install"} {
+		if byName[name].ParentID != tool.SpanID {
+			t.Errorf("%s span parent = %q, expected the tool span", name, byName[name].ParentID)
+		}
+	}
+	if got := byName["hook preinstall"].End.Sub(byName["hook preinstall"].Start); got != 5*time.Millisecond {
+		t.Errorf("recorded hook duration = %v, expected 5ms", got)
+	}
+	if cmd := byName["mise install"]; cmd.Error != "mise install failed" || len(cmd.Attrs) != 2 {
+		t.Errorf("command span = %+v", cmd)
+	}
+}
+
+func TestRecorder_WriteChrome(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleTrace().WriteChrome(&buf); err != nil {
+		t.Fatalf("WriteChrome failed: %v", err)
+	}
+
+	var doc struct {
+		TraceEvents []struct {
+			Name  string         `json:"name"`
+			Cat   string         `json:"cat"`
+			Phase string         `json:"ph"`
+			TS    int64          `json:"ts"`
+			Dur   int64          `json:"dur"`
+			Args  map[string]any `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(doc.TraceEvents) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(doc.TraceEvents))
+	}
+	for _, e := range doc.TraceEvents {
+		if e.Phase != "X" || e.TS == 0 {
+			t.Errorf("event %q: ph %q ts %d", e.Name, e.Phase, e.TS)
+		}
+		switch e.Name {
+		case "hook preinstall":
+			if e.Dur != 5000 || e.Cat != "hook" {
+				t.Errorf("hook event dur %d cat %q, expected 5000 and hook", e.Dur, e.Cat)
+			}
+		case "mise install":
+			if e.Args["error"] != "mise install failed" || e.Args["exit_code"] != float64(1) {
+				t.Errorf("command event args = %v", e.Args)
+			}
+		}
+	}
+}
+
+func TestRecorder_WriteOTLP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleTrace().WriteOTLP(&buf); err != nil {
+		t.Fatalf("WriteOTLP failed: %v", err)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request shape: %s", buf.String())
+	}
+	if attr := req.ResourceSpans[0].Resource.Attributes[0]; attr.Key != "service.name" || attr.Value["stringValue"] != "mise-seq" {
+		t.Errorf("resource attribute = %+v", attr)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	for _, s := range spans {
+		if s.Name != "mise install" {
+			continue
+		}
+		if s.Status.Code != 2 || s.Status.Message != "mise install failed" {
+			t.Errorf("failed span status = %+v", s.Status)
+		}
+		if s.ParentSpanID == "" || s.StartTimeUnixNano == "" {
+			t.Errorf("span = %+v", s)
+		}
+		if !strings.Contains(buf.String(), `"intValue": "1"`) {
+			t.Errorf("exit code not exported as intValue:\n%s", buf.String())
+		}
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Target
+		wantErr bool
+	}{
+		{"chrome=trace.json", Target{FormatChrome, "trace.json"}, false},
+		{"otlp=out/trace.otlp.json", Target{FormatOTLP, "out/trace.otlp.json"}, false},
+		{"otlp=http://localhost:4318", Target{FormatOTLP, "http://localhost:4318"}, false},
+		{"chrome=http://localhost:4318", Target{}, true},
+		{"zipkin=trace.json", Target{}, true},
+		{"trace.json", Target{}, true},
+		{"chrome=", Target{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTarget(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTarget(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTarget(%q) = %+v, expected %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder_ExportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "trace.json")
+	if err := sampleTrace().Export(context.Background(), Target{FormatChrome, path}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("trace not written: %v", err)
+	}
+	if !strings.Contains(string(data), "traceEvents") {
+		t.Errorf("unexpected trace file:\n%s", data)
+	}
+}
+
+func TestRecorder_ExportCollector(t *testing.T) {
+	var gotPath, gotType string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotType = r.URL.Path, r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	if err := sampleTrace().Export(context.Background(), Target{FormatOTLP, server.URL}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if gotPath != "/v1/traces" || gotType != "application/json" {
+		t.Errorf("posted to %q with %q, expected /v1/traces and application/json", gotPath, gotType)
+	}
+	if !json.Valid(gotBody) || !bytes.Contains(gotBody, []byte("resourceSpans")) {
+		t.Errorf("unexpected body:\n%s", gotBody)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	if err := sampleTrace().Export(context.Background(), Target{FormatOTLP, failing.URL + "/custom"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected a 400 error, got %v", err)
+	}
+}